@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+// maxAnswerWidth caps the line width of answers in table output
+const maxAnswerWidth = 80
+
+func answerCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "answer",
+		Usage:     "Get a synthesized answer to a question, with citations",
+		ArgsUsage: "<question>",
+		UsageText: `Examples:
+  exa answer "What is the capital of Australia?"
+  exa answer -q "Who maintains the Go toolchain?" | pbcopy`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "text",
+				Usage: "Include the full text of each citation",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() == 0 {
+				return fmt.Errorf("question is required")
+			}
+			question := cmd.Args().First()
+			if strings.TrimSpace(question) == "" {
+				return fmt.Errorf("question is empty")
+			}
+			if err := validateOutputFlags(cmd); err != nil {
+				return err
+			}
+
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			defer warnLowQuota(c)
+
+			req := &client.AnswerRequest{Query: question, Text: cmd.Bool("text")}
+			if err := echoRequest(cmd, req); err != nil {
+				return err
+			}
+			ctx, cancel := withTimeout(ctx, cmd)
+			defer cancel()
+			resp, err := c.Answer(ctx, req)
+			if err != nil {
+				return timeoutErr(ctx, err)
+			}
+			return printOutput(cmd, resp)
+		},
+	}
+}
+
+// printAnswer writes the answer followed by a numbered list of citation URLs.
+// On a terminal the answer is wrapped to fit; written to a file or pipe it
+// keeps its own line breaks.
+func printAnswer(w io.Writer, resp *client.AnswerResponse) {
+	answer := strings.TrimSpace(resp.Answer)
+	if isTerminal() {
+		width := maxAnswerWidth
+		if tw, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && tw < width {
+			width = tw
+		}
+		answer = wrapText(answer, width)
+	}
+	fmt.Fprintln(w, answer)
+
+	if len(resp.Citations) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Sources:")
+	for i, r := range resp.Citations {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, r.URL)
+	}
+}
+
+// wrapText word-wraps each paragraph of s to lines of at most width
+// characters. Words longer than width get a line of their own.
+func wrapText(s string, width int) string {
+	paragraphs := strings.Split(strings.TrimSpace(s), "\n")
+	for i, p := range paragraphs {
+		var b strings.Builder
+		lineLen := 0
+		for _, word := range strings.Fields(p) {
+			n := len([]rune(word))
+			if lineLen > 0 && lineLen+1+n > width {
+				b.WriteByte('\n')
+				lineLen = 0
+			} else if lineLen > 0 {
+				b.WriteByte(' ')
+				lineLen++
+			}
+			b.WriteString(word)
+			lineLen += n
+		}
+		paragraphs[i] = b.String()
+	}
+	return strings.Join(paragraphs, "\n")
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/12458/exa-cli/internal/client"
+)
+
+func TestAnswerToFileIsNotWrapped(t *testing.T) {
+	answer := strings.Repeat("Tokio is the most widely used asynchronous runtime for Rust. ", 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(client.AnswerResponse{Answer: answer})
+	}))
+	t.Cleanup(srv.Close)
+
+	stdout, stderr, err := runCLI(t, srv.URL, "answer", "what is the most popular rust async runtime")
+	if err != nil {
+		t.Fatalf("answer: %v\nstderr: %s", err, stderr)
+	}
+	if want := strings.TrimSpace(answer) + "\n"; stdout != want {
+		t.Errorf("got %q, want the answer on one line", stdout)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/12458/exa-cli/internal/cache"
+	"github.com/urfave/cli/v3"
+)
+
+func cacheCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "Inspect or clear the local cache (--diff page versions, --new-only history)",
+		Commands: []*cli.Command{
+			{
+				Name:  "info",
+				Usage: "Show the cache location, entry count and size",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					dir, err := cache.Dir()
+					if err != nil {
+						return err
+					}
+					stats, err := cache.Info()
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Location: %s\n", dir)
+					fmt.Printf("Entries:  %d\n", stats.Entries)
+					fmt.Printf("Size:     %s\n", formatBytes(stats.Bytes))
+					return nil
+				},
+			},
+			{
+				Name:      "clear",
+				Usage:     "Delete cache entries, asking for confirmation unless --yes is set",
+				UsageText: "exa cache clear [--older-than 720h]",
+				Flags: []cli.Flag{
+					&cli.DurationFlag{
+						Name:  "older-than",
+						Usage: "Only delete entries last written longer ago than this (e.g. 720h)",
+					},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					olderThan := cmd.Duration("older-than")
+					if olderThan < 0 {
+						return fmt.Errorf("older-than must not be negative")
+					}
+					dir, err := cache.Dir()
+					if err != nil {
+						return err
+					}
+					stats, err := cache.Info()
+					if err != nil {
+						return err
+					}
+					if stats.Entries == 0 {
+						fmt.Println("Cache is empty")
+						return nil
+					}
+
+					if !cmd.Root().Bool("yes") {
+						question := fmt.Sprintf("Delete all %d cache entries (%s) in %s?", stats.Entries, formatBytes(stats.Bytes), dir)
+						if olderThan > 0 {
+							question = fmt.Sprintf("Delete cache entries older than %s in %s?", olderThan, dir)
+						}
+						if !confirm(question) {
+							return fmt.Errorf("cache not cleared")
+						}
+					}
+
+					removed, err := cache.Clear(olderThan)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Deleted %d entries (%s)\n", removed.Entries, formatBytes(removed.Bytes))
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// formatBytes formats a byte count with a binary unit (e.g. "1.5 MiB")
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
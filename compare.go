@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/12458/exa-cli/internal/client"
+
+	"github.com/fatih/color"
+)
+
+// savedResults matches both the JSON output envelope and raw API responses,
+// which all carry results under "results"
+type savedResults struct {
+	Results []client.SearchResult `json:"results"`
+}
+
+// loadResults reads the results from a previously saved JSON output file, or
+// from stdin if path is "-"
+func loadResults(path string) ([]client.SearchResult, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results file: %w", err)
+	}
+
+	var saved savedResults
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse results file %s: %w", path, err)
+	}
+	return saved.Results, nil
+}
+
+// resultChange is a result present in both the baseline and the current
+// results whose rank or score changed
+type resultChange struct {
+	URL      string  `json:"url" toon:"url"`
+	Title    string  `json:"title" toon:"title"`
+	OldRank  int     `json:"oldRank" toon:"oldRank"`
+	NewRank  int     `json:"newRank" toon:"newRank"`
+	OldScore float64 `json:"oldScore,omitempty" toon:"oldScore,omitempty"`
+	NewScore float64 `json:"newScore,omitempty" toon:"newScore,omitempty"`
+}
+
+// resultComparison is the difference between a saved baseline and the current
+// results, keyed by URL
+type resultComparison struct {
+	Added   []client.SearchResult `json:"added" toon:"added"`
+	Removed []client.SearchResult `json:"removed" toon:"removed"`
+	Changed []resultChange        `json:"changed" toon:"changed"`
+}
+
+// compareResults diffs current against baseline by URL, reporting added and
+// removed results and results whose rank or score changed
+func compareResults(baseline, current []client.SearchResult) *resultComparison {
+	cmp := &resultComparison{
+		Added:   []client.SearchResult{},
+		Removed: []client.SearchResult{},
+		Changed: []resultChange{},
+	}
+
+	oldRank := make(map[string]int, len(baseline))
+	for i, r := range baseline {
+		oldRank[r.URL] = i
+	}
+	newURLs := make(map[string]bool, len(current))
+
+	for i, r := range current {
+		newURLs[r.URL] = true
+		j, ok := oldRank[r.URL]
+		if !ok {
+			cmp.Added = append(cmp.Added, r)
+			continue
+		}
+		old := baseline[j]
+		if i != j || old.Score != r.Score {
+			cmp.Changed = append(cmp.Changed, resultChange{
+				URL:      r.URL,
+				Title:    r.Title,
+				OldRank:  j + 1,
+				NewRank:  i + 1,
+				OldScore: old.Score,
+				NewScore: r.Score,
+			})
+		}
+	}
+
+	for _, r := range baseline {
+		if !newURLs[r.URL] {
+			cmp.Removed = append(cmp.Removed, r)
+		}
+	}
+
+	return cmp
+}
+
+func printComparison(w io.Writer, cmp *resultComparison) {
+	color.NoColor = !colorEnabled()
+	addFmt := color.New(color.FgGreen).SprintFunc()
+	delFmt := color.New(color.FgRed).SprintFunc()
+	chgFmt := color.New(color.FgYellow).SprintFunc()
+
+	if len(cmp.Added) == 0 && len(cmp.Removed) == 0 && len(cmp.Changed) == 0 {
+		fmt.Fprintln(w, "No changes")
+		return
+	}
+
+	for _, r := range cmp.Added {
+		fmt.Fprintln(w, addFmt(fmt.Sprintf("+ %s  %s", r.URL, r.Title)))
+	}
+	for _, r := range cmp.Removed {
+		fmt.Fprintln(w, delFmt(fmt.Sprintf("- %s  %s", r.URL, r.Title)))
+	}
+	for _, c := range cmp.Changed {
+		line := fmt.Sprintf("~ %s  rank %d -> %d", c.URL, c.OldRank, c.NewRank)
+		if c.OldScore != c.NewScore {
+			line += fmt.Sprintf(", score %.3f -> %.3f", c.OldScore, c.NewScore)
+		}
+		fmt.Fprintln(w, chgFmt(line))
+	}
+	fmt.Fprintf(w, "\n%d added, %d removed, %d changed\n", len(cmp.Added), len(cmp.Removed), len(cmp.Changed))
+}
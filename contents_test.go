@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestContentsBatchAuthErrorStopsRemainingBatches(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"invalid API key"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	_, stderr, err := runCLI(t, srv.URL, "contents", "--batch-size", "1", "--concurrency", "1",
+		"https://one.example/", "https://two.example/", "https://three.example/")
+	if err == nil {
+		t.Fatal("got nil error for a rejected API key")
+	}
+	if code := exitCode(err); code != exitAuth {
+		t.Errorf("exit code %d, want %d (auth): %v\nstderr: %s", code, exitAuth, err, stderr)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("server got %d requests, want 1: the batches after the 401 should not be sent", n)
+	}
+}
+
+func TestContentsBatchSizeRange(t *testing.T) {
+	for _, size := range []string{"0", "-1", "101"} {
+		_, _, err := runCLI(t, "http://exa.invalid", "contents", "--batch-size", size, "https://one.example/")
+		if err == nil || !strings.Contains(err.Error(), "batch-size must be between 1 and 100") {
+			t.Errorf("--batch-size %s: got %v, want a range error", size, err)
+		}
+	}
+}
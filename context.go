@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/urfave/cli/v3"
+)
+
+// charsPerToken is the rough number of characters per token used by
+// estimateTokens, a common rule of thumb for English text
+const charsPerToken = 4
+
+// tokenEstimator returns the approximate number of LLM tokens in s
+type tokenEstimator func(s string) int
+
+// estimateTokens is the default tokenEstimator, counting one token per
+// charsPerToken characters
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + charsPerToken - 1) / charsPerToken
+}
+
+// assembleContext combines the text of results into a single RAG context
+// string, adding whole sources in order until the next one would exceed
+// maxTokens. Results without text are skipped. It returns the context and the
+// number of sources included.
+func assembleContext(results []client.SearchResult, maxTokens int, estimate tokenEstimator) (string, int) {
+	var b strings.Builder
+	used, included := 0, 0
+	for _, r := range results {
+		if r.Text == "" {
+			continue
+		}
+		source := fmt.Sprintf("## %s\n%s\n\n%s", resultHeading(r), r.URL, r.Text)
+		if included > 0 {
+			source = "\n\n" + source
+		}
+		tokens := estimate(source)
+		if used+tokens > maxTokens {
+			break
+		}
+		b.WriteString(source)
+		used += tokens
+		included++
+	}
+	return b.String(), included
+}
+
+// trimTextTotal cuts the text of results, in rank order, so their combined
+// length is at most maxChars characters: earlier results keep their full text
+// and later ones are shortened or emptied. It returns the number of results
+// cut and the characters removed.
+func trimTextTotal(results []client.SearchResult, maxChars int) (trimmed, removed int) {
+	remaining := maxChars
+	for i := range results {
+		text := []rune(results[i].Text)
+		if len(text) <= remaining {
+			remaining -= len(text)
+			continue
+		}
+		results[i].Text = string(text[:remaining])
+		removed += len(text) - remaining
+		remaining = 0
+		trimmed++
+	}
+	return trimmed, removed
+}
+
+// applyMaxCharsTotal applies --max-chars-total to results, reporting what was
+// cut on stderr. Zero means no limit.
+func applyMaxCharsTotal(cmd *cli.Command, results []client.SearchResult) {
+	maxChars := int(cmd.Int("max-chars-total"))
+	if maxChars <= 0 {
+		return
+	}
+	if trimmed, removed := trimTextTotal(results, maxChars); trimmed > 0 {
+		fmt.Fprintf(os.Stderr, "Trimmed text of %d result(s) by %d chars to fit %d total\n", trimmed, removed, maxChars)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/urfave/cli/v3"
+)
+
+// utf8BOM marks a file as UTF-8 for spreadsheet applications such as Excel
+const utf8BOM = "\ufeff"
+
+// csvHeader lists the columns written by printResultsCSV
+var csvHeader = []string{"title", "url", "published_date", "author", "score", "summary", "text"}
+
+// printCSV writes search or contents results as CSV. Other values fall back
+// to JSON.
+func printCSV(w io.Writer, cmd *cli.Command, v any) error {
+	var results []client.SearchResult
+	switch resp := v.(type) {
+	case *client.SearchResponse:
+		results = resp.Results
+	case *client.ContentsResponse:
+		results = resp.Results
+	default:
+		return printJSON(w, v)
+	}
+
+	columns, err := schemaColumns(cmd)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Root().Bool("csv-bom") {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return err
+		}
+	}
+	if columns != nil {
+		return printSchemaCSV(w, results, columns)
+	}
+	return printResultsCSV(w, results)
+}
+
+// csvOutputHeader returns the header row printCSV writes for cmd
+func csvOutputHeader(cmd *cli.Command) ([]string, error) {
+	columns, err := schemaColumns(cmd)
+	if err != nil || columns == nil {
+		return csvHeader, err
+	}
+	return append([]string{"title", "url"}, columns...), nil
+}
+
+// stripCSVHeader removes the BOM and header row from CSV output written by
+// printCSV, for appending to a file that already has them. Output that doesn't
+// start with the header, such as the JSON fallback, is returned unchanged.
+func stripCSVHeader(cmd *cli.Command, out []byte) []byte {
+	header, err := csvOutputHeader(cmd)
+	if err != nil {
+		return out
+	}
+	body := bytes.TrimPrefix(out, []byte(utf8BOM))
+	r := csv.NewReader(bytes.NewReader(body))
+	r.FieldsPerRecord = -1
+	if first, err := r.Read(); err != nil || !slices.Equal(first, header) {
+		return out
+	}
+	return body[r.InputOffset():]
+}
+
+// printResultsCSV writes one CSV row per result after a header row
+func printResultsCSV(w io.Writer, results []client.SearchResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range results {
+		score := ""
+		if r.Score != 0 {
+			score = strconv.FormatFloat(r.Score, 'f', -1, 64)
+		}
+		row := []string{resultTitle(r), r.URL, r.PublishedDate, r.Author, score, r.Summary, r.Text}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// schemaColumns returns the top-level property names of --summary-schema, in
+// schema order, when --columns-from-schema is set. It returns nil if the flag
+// is unset, and an error if it can't be honoured.
+func schemaColumns(cmd *cli.Command) ([]string, error) {
+	if !cmd.Bool("columns-from-schema") {
+		return nil, nil
+	}
+	if getOutputFormat(cmd) != "csv" && cmd.Root().String("also-csv") == "" {
+		return nil, fmt.Errorf("--columns-from-schema requires --output csv or --also-csv")
+	}
+	schema := cmd.String("summary-schema")
+	if schema == "" {
+		return nil, fmt.Errorf("--columns-from-schema requires --summary-schema")
+	}
+
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(schema), &top); err != nil {
+		return nil, fmt.Errorf("invalid summary-schema JSON: %w", err)
+	}
+	properties, ok := top["properties"]
+	if !ok {
+		return nil, fmt.Errorf("summary-schema has no top-level properties to use as columns")
+	}
+
+	// Walk the properties object token by token to keep the schema's order
+	dec := json.NewDecoder(bytes.NewReader(properties))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("summary-schema properties must be an object")
+	}
+	var columns []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid summary-schema JSON: %w", err)
+		}
+		columns = append(columns, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, fmt.Errorf("invalid summary-schema JSON: %w", err)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("summary-schema has no top-level properties to use as columns")
+	}
+	return columns, nil
+}
+
+// printSchemaCSV writes one row per result with a column for each schema
+// property, taken from the result's structured summary. Cells are left empty
+// when a summary is missing, isn't a JSON object, or lacks the property.
+// Non-string values are written as JSON.
+func printSchemaCSV(w io.Writer, results []client.SearchResult, columns []string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"title", "url"}, columns...)); err != nil {
+		return err
+	}
+	for _, r := range results {
+		var extracted map[string]any
+		_ = json.Unmarshal([]byte(r.Summary), &extracted)
+
+		row := []string{resultTitle(r), r.URL}
+		for _, col := range columns {
+			row = append(row, schemaCell(extracted[col]))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// schemaCell formats an extracted summary value as a CSV cell
+func schemaCell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
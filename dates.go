@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/urfave/cli/v3"
+)
+
+// dateLayouts are the published date formats seen in Exa results, tried in
+// order by parseDate
+var dateLayouts = []string{
+	time.RFC3339, // also accepts fractional seconds
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.DateOnly,
+	time.RFC1123,
+	time.RFC1123Z,
+	"2006-01",
+	"2006",
+}
+
+// parseDate parses a published date in any of dateLayouts. ok is false if s
+// is empty or matches none of them.
+func parseDate(s string) (t time.Time, ok bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sortOrders are the values accepted by search --sort
+var sortOrders = []string{"relevance", "date"}
+
+// sortResults reorders results for --sort. "relevance" keeps the API's order.
+func sortResults(results []client.SearchResult, order string) error {
+	switch order {
+	case "", "relevance":
+		return nil
+	case "date":
+		sortByDate(results)
+		return nil
+	default:
+		return fmt.Errorf("invalid --sort %q (valid: %s)", order, strings.Join(sortOrders, ", "))
+	}
+}
+
+// sortByDate sorts results newest first. Each date is parsed once up front;
+// results with a missing or unparseable date sort last, in their original
+// order.
+func sortByDate(results []client.SearchResult) {
+	type dated struct {
+		result client.SearchResult
+		date   time.Time
+		ok     bool
+	}
+	sorted := make([]dated, len(results))
+	for i, r := range results {
+		t, ok := parseDate(r.PublishedDate)
+		sorted[i] = dated{r, t, ok}
+	}
+	slices.SortStableFunc(sorted, func(a, b dated) int {
+		switch {
+		case a.ok && b.ok:
+			return b.date.Compare(a.date)
+		case a.ok != b.ok:
+			// Parsed dates first
+			if a.ok {
+				return -1
+			}
+			return 1
+		}
+		return 0
+	})
+	for i, d := range sorted {
+		results[i] = d.result
+	}
+}
+
+// publishedRange parses --min-published and --max-published. The range is
+// half-open: max is the end of the period named by --max-published, so
+// "2024-06" includes all of June. Unset bounds are zero.
+func publishedRange(cmd *cli.Command) (minDate, maxDate time.Time, err error) {
+	if s := cmd.String("min-published"); s != "" {
+		var ok bool
+		if minDate, ok = parseDate(s); !ok {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --min-published date %q (use YYYY-MM-DD or RFC 3339)", s)
+		}
+	}
+	if s := cmd.String("max-published"); s != "" {
+		var ok bool
+		if maxDate, ok = parseDate(s); !ok {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --max-published date %q (use YYYY-MM-DD or RFC 3339)", s)
+		}
+		switch len(strings.TrimSpace(s)) {
+		case len("2006"):
+			maxDate = maxDate.AddDate(1, 0, 0)
+		case len("2006-01"):
+			maxDate = maxDate.AddDate(0, 1, 0)
+		case len(time.DateOnly):
+			maxDate = maxDate.AddDate(0, 0, 1)
+		default:
+			maxDate = maxDate.Add(time.Nanosecond)
+		}
+	}
+	if !minDate.IsZero() && !maxDate.IsZero() && !minDate.Before(maxDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--min-published must be before --max-published")
+	}
+	return minDate, maxDate, nil
+}
+
+// filterPublished keeps the results published in [minDate, maxDate), ignoring
+// zero bounds. Results without a parseable date are dropped unless
+// keepUndated is set.
+func filterPublished(results []client.SearchResult, minDate, maxDate time.Time, keepUndated bool) []client.SearchResult {
+	if minDate.IsZero() && maxDate.IsZero() {
+		return results
+	}
+	kept := results[:0]
+	for _, r := range results {
+		t, ok := parseDate(r.PublishedDate)
+		switch {
+		case !ok:
+			if !keepUndated {
+				continue
+			}
+		case !minDate.IsZero() && t.Before(minDate):
+			continue
+		case !maxDate.IsZero() && !t.Before(maxDate):
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
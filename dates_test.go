@@ -0,0 +1,61 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/12458/exa-cli/internal/client"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		want   time.Time
+		wantOK bool
+	}{
+		{"RFC 3339", "2024-03-15T10:30:00Z", time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC), true},
+		{"RFC 3339 fractional", "2024-03-15T10:30:00.123+02:00", time.Date(2024, 3, 15, 8, 30, 0, 123e6, time.UTC), true},
+		{"no zone", "2024-03-15T10:30:00", time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC), true},
+		{"space separated", "2024-03-15 10:30:00", time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC), true},
+		{"date only", "2024-03-15", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), true},
+		{"RFC 1123", "Fri, 15 Mar 2024 10:30:00 UTC", time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC), true},
+		{"RFC 1123 numeric zone", "Fri, 15 Mar 2024 10:30:00 -0500", time.Date(2024, 3, 15, 15, 30, 0, 0, time.UTC), true},
+		{"year and month", "2024-03", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"year", "2024", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"surrounding space", "  2024-03-15 ", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), true},
+		{"empty", "", time.Time{}, false},
+		{"blank", "   ", time.Time{}, false},
+		{"bad input", "15/03/2024", time.Time{}, false},
+		{"bad month", "2024-13-01", time.Time{}, false},
+		{"words", "last tuesday", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseDate(tt.in)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("parseDate(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSortByDate(t *testing.T) {
+	results := []client.SearchResult{
+		{URL: "undated-1"},
+		{URL: "old", PublishedDate: "2020-01-01"},
+		{URL: "new", PublishedDate: "2024-06-01T00:00:00Z"},
+		{URL: "bad", PublishedDate: "yesterday"},
+		{URL: "mid", PublishedDate: "2022"},
+	}
+	sortByDate(results)
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.URL)
+	}
+	if want := []string{"new", "mid", "old", "undated-1", "bad"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
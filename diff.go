@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/12458/exa-cli/internal/cache"
+	"github.com/12458/exa-cli/internal/client"
+
+	"github.com/fatih/color"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// contentsCacheNamespace is the cache namespace holding previously fetched
+// page text, keyed by URL
+const contentsCacheNamespace = cache.ContentsNamespace
+
+// cachedContent is a page version stored for later comparison
+type cachedContent struct {
+	URL       string    `json:"url"`
+	Text      string    `json:"text"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// printContentsDiff compares each result's text against the cached version of
+// the same URL, writes a unified diff to w, and caches the new version.
+// Results that failed to fetch or came back without text are neither
+// compared nor cached, so a failed fetch never replaces a good version.
+func printContentsDiff(w io.Writer, resp *client.ContentsResponse) error {
+	color.NoColor = !colorEnabled()
+	addFmt := color.New(color.FgGreen).SprintFunc()
+	delFmt := color.New(color.FgRed).SprintFunc()
+	hunkFmt := color.New(color.FgCyan).SprintFunc()
+
+	now := time.Now()
+	for i, r := range withStatuses(resp) {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		if r.Status != "" && r.Status != "success" {
+			reason := r.Status
+			if r.Error != nil && r.Error.Tag != "" {
+				reason = r.Error.Tag
+			}
+			fmt.Fprintf(w, "%s: not compared, fetch failed (%s)\n", r.URL, reason)
+			continue
+		}
+		if r.Text == "" {
+			fmt.Fprintf(w, "%s: not compared, no text returned\n", r.URL)
+			continue
+		}
+
+		key := r.ID
+		if key == "" {
+			key = r.URL
+		}
+
+		var prev cachedContent
+		found, err := cache.Load(contentsCacheNamespace, key, &prev)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			fmt.Fprintf(w, "%s: no cached version, saved current version for future diffs\n", r.URL)
+		} else {
+			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        difflib.SplitLines(prev.Text),
+				B:        difflib.SplitLines(r.Text),
+				FromFile: "cached",
+				FromDate: prev.FetchedAt.Format(time.RFC3339),
+				ToFile:   "current",
+				ToDate:   now.Format(time.RFC3339),
+				Context:  3,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to diff %s: %w", r.URL, err)
+			}
+
+			if diff == "" {
+				fmt.Fprintf(w, "%s: no changes since %s\n", r.URL, prev.FetchedAt.Format(time.RFC3339))
+			} else {
+				fmt.Fprintf(w, "%s:\n", r.URL)
+				for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+					switch {
+					case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+						fmt.Fprintln(w, line)
+					case strings.HasPrefix(line, "+"):
+						fmt.Fprintln(w, addFmt(line))
+					case strings.HasPrefix(line, "-"):
+						fmt.Fprintln(w, delFmt(line))
+					case strings.HasPrefix(line, "@@"):
+						fmt.Fprintln(w, hunkFmt(line))
+					default:
+						fmt.Fprintln(w, line)
+					}
+				}
+			}
+		}
+
+		entry := cachedContent{URL: r.URL, Text: r.Text, FetchedAt: now}
+		if err := cache.Save(contentsCacheNamespace, key, entry); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to cache %s: %v\n", r.URL, err)
+		}
+	}
+	return nil
+}
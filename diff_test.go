@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/12458/exa-cli/internal/cache"
+	"github.com/12458/exa-cli/internal/client"
+)
+
+// diffContents runs printContentsDiff on a single result with the given
+// text and status, returning the output
+func diffContents(t *testing.T, text, status string) string {
+	t.Helper()
+	resp := &client.ContentsResponse{
+		Results: []client.SearchResult{{ID: "https://example.com/", URL: "https://example.com/", Text: text}},
+	}
+	if status != "" {
+		st := client.ContentStatus{ID: "https://example.com/", Status: status}
+		if status != "success" {
+			st.Error = &client.ContentError{Tag: "CRAWL_TIMEOUT"}
+		}
+		resp.Statuses = []client.ContentStatus{st}
+	}
+	var buf bytes.Buffer
+	if err := printContentsDiff(&buf, resp); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestContentsDiffSkipsFailedAndEmptyFetches(t *testing.T) {
+	cache.SetDir(t.TempDir())
+	t.Cleanup(func() { cache.SetDir("") })
+	orig := colorMode
+	colorMode = "never"
+	t.Cleanup(func() { colorMode = orig })
+
+	if out := diffContents(t, "version one\n", "success"); !strings.Contains(out, "no cached version") {
+		t.Fatalf("first run: %q", out)
+	}
+
+	if out := diffContents(t, "", "error"); !strings.Contains(out, "not compared, fetch failed (CRAWL_TIMEOUT)") {
+		t.Errorf("failed fetch: %q", out)
+	}
+	if out := diffContents(t, "", "success"); !strings.Contains(out, "not compared, no text returned") {
+		t.Errorf("empty text: %q", out)
+	}
+
+	// Neither replaced the cached version
+	var cached cachedContent
+	if found, err := cache.Load(contentsCacheNamespace, "https://example.com/", &cached); err != nil || !found || cached.Text != "version one\n" {
+		t.Fatalf("cached %+v (found %v, %v), want version one", cached, found, err)
+	}
+
+	out := diffContents(t, "version two\n", "")
+	if !strings.Contains(out, "-version one") || !strings.Contains(out, "+version two") {
+		t.Errorf("diff against the cached version: %q", out)
+	}
+}
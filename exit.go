@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/12458/exa-cli/internal/client"
+)
+
+// Exit codes, listed in the root command's help
+const (
+	exitError     = 1 // any other error: bad flags, config, output
+	exitAuth      = 2 // API key rejected (401/403)
+	exitRateLimit = 3 // rate limited (429)
+	exitAPI       = 4 // any other API error status
+	exitNetwork   = 5 // network failure or timeout
+	exitEmpty     = 6 // search --fail-on-empty found no results
+)
+
+// exitHelp documents the exit codes in the root command's help
+const exitHelp = `Exit codes:
+  0  success
+  1  error (invalid flags, config file, output)
+  2  API key rejected (HTTP 401/403)
+  3  rate limited (HTTP 429)
+  4  other API error
+  5  network failure or timeout
+  6  no results (search --fail-on-empty)`
+
+// errNoResults is returned by search --fail-on-empty when nothing was found
+var errNoResults = &exitCodeError{exitEmpty, errors.New("no results")}
+
+// exitCodeError is an error that sets the process exit code
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// exitCode maps err to the process exit code: an exitCodeError's own code,
+// or the code for the kind of API or network error it wraps. Finding no
+// results ranks below any failure joined with it, since a run with failed
+// calls didn't see everything there was to find.
+func exitCode(err error) int {
+	var codeErr *exitCodeError
+	if errors.As(err, &codeErr) && codeErr.code != exitEmpty {
+		return codeErr.code
+	}
+	var statusErr *client.StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return exitAuth
+		case http.StatusTooManyRequests:
+			return exitRateLimit
+		}
+		return exitAPI
+	}
+	if errors.Is(err, context.Canceled) {
+		return exitError
+	}
+	var netErr *client.NetworkError
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return exitNetwork
+	}
+	var failed *failuresError
+	if errors.As(err, &failed) {
+		return exitError
+	}
+	if errors.Is(err, errNoResults) {
+		return exitEmpty
+	}
+	return exitError
+}
+
+// exit prints err and exits with its exit code
+func exit(err error) {
+	log.Print(err)
+	os.Exit(exitCode(err))
+}
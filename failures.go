@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/urfave/cli/v3"
+)
+
+// failures collects the errors of a command that makes several API calls
+// (batched contents, research), following the --fail-fast or --best-effort
+// policy
+type failures struct {
+	failFast bool
+	errs     []error
+}
+
+// newFailures returns a collector for cmd's failure policy. Best effort is
+// the default.
+func newFailures(cmd *cli.Command) *failures {
+	return &failures{failFast: cmd.Root().Bool("fail-fast")}
+}
+
+// add records the failure of op. It returns the error if the command should
+// stop: always with --fail-fast, and under --best-effort for fatal errors
+// (auth or validation, which every remaining call would hit too) and
+// cancellation. Otherwise the failure is reported as a warning and nil is
+// returned so the command carries on.
+func (f *failures) add(op string, err error) error {
+	err = fmt.Errorf("%s: %w", op, err)
+	if f.stops(err) {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	f.errs = append(f.errs, err)
+	return nil
+}
+
+// stops reports whether err should stop the command, as described for add
+func (f *failures) stops(err error) bool {
+	return f.failFast || client.IsFatal(err) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// err prints a summary of the recorded failures to stderr and returns an
+// error so the command exits non-zero, or nil if nothing failed. The error
+// wraps the recorded failures, so exitCode maps it like the first of them.
+func (f *failures) err() error {
+	if len(f.errs) == 0 {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "%d operation(s) failed:\n", len(f.errs))
+	for _, err := range f.errs {
+		fmt.Fprintf(os.Stderr, "  - %v\n", err)
+	}
+	return &failuresError{errs: f.errs}
+}
+
+// failuresError is the error returned for the failures of a best-effort
+// command
+type failuresError struct {
+	errs []error
+}
+
+func (e *failuresError) Error() string {
+	return fmt.Sprintf("%d operation(s) failed (use --fail-fast to stop at the first error)", len(e.errs))
+}
+
+func (e *failuresError) Unwrap() []error {
+	return e.errs
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/12458/exa-cli/internal/client"
+)
+
+func TestFailuresErrExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		errs []error
+		want int
+	}{
+		{"auth", []error{&client.StatusError{StatusCode: http.StatusUnauthorized}}, exitAuth},
+		{"rate limit", []error{&client.StatusError{StatusCode: http.StatusTooManyRequests}}, exitRateLimit},
+		{"api", []error{&client.StatusError{StatusCode: http.StatusBadGateway}}, exitAPI},
+		{"network", []error{&client.NetworkError{Err: errors.New("connection refused")}}, exitNetwork},
+		{"other", []error{errors.New("boom")}, exitError},
+		{"first API error wins", []error{errors.New("boom"), &client.StatusError{StatusCode: http.StatusTooManyRequests}}, exitRateLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Fatal errors stop a command before err is reached, but
+			// they still map through the wrapped errors
+			f := &failures{errs: tt.errs}
+			var err error
+			captureStderr(t, func() { err = f.err() })
+			if err == nil {
+				t.Fatal("got nil error")
+			}
+			if got := exitCode(err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailuresErrNone(t *testing.T) {
+	if err := (&failures{}).err(); err != nil {
+		t.Errorf("got %v, want nil with no failures", err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+// contentFlags are the content options search and find-similar request
+// inline with their results
+func contentFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "text",
+			Usage: "Include full text content",
+		},
+		&cli.IntFlag{
+			Name:  "text-max-chars",
+			Usage: "Maximum characters for text content",
+		},
+		&cli.BoolFlag{
+			Name:  "text-include-html",
+			Usage: "Include HTML tags in text content",
+		},
+		&cli.StringFlag{
+			Name:  "text-verbosity",
+			Usage: "Text verbosity: compact, standard, full",
+		},
+		&cli.BoolFlag{
+			Name:    "highlights",
+			Aliases: []string{"H"},
+			Usage:   "Include highlights",
+		},
+		&cli.BoolFlag{
+			Name:    "summary",
+			Aliases: []string{"s"},
+			Usage:   "Include AI-generated summary",
+		},
+		&cli.StringFlag{
+			Name:  "summary-query",
+			Usage: "Custom query for summary generation (@file to read a file, - for stdin)",
+		},
+		&cli.StringFlag{
+			Name:  "summary-schema",
+			Usage: "JSON schema for structured summary extraction (@file to read a file, - for stdin)",
+		},
+	}
+}
+
+// domainFlags are the API's domain filters
+func domainFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    "include-domains",
+			Aliases: []string{"i"},
+			Usage:   "Only include results from these domains",
+		},
+		&cli.StringSliceFlag{
+			Name:    "exclude-domains",
+			Aliases: []string{"x"},
+			Usage:   "Exclude results from these domains",
+		},
+	}
+}
+
+// publishedDateFlags are the API's publish date filters
+func publishedDateFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "start-published-date",
+			Usage: "Filter by publish date (ISO 8601)",
+		},
+		&cli.StringFlag{
+			Name:  "end-published-date",
+			Usage: "Filter by publish date (ISO 8601)",
+		},
+	}
+}
+
+// scoreFlags control how result lists show scores, named after what the
+// score measures, e.g. "relevance"
+func scoreFlags(score string) []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "show-scores",
+			Usage: fmt.Sprintf("Show the %s score column in table output, and scores in markdown frontmatter", score),
+		},
+		&cli.BoolFlag{
+			Name:  "with-metadata",
+			Usage: "With --quiet, print tab-separated url, title, score and date instead of just URLs",
+		},
+		&cli.IntFlag{
+			Name:  "score-precision",
+			Usage: "Decimal places for displayed scores",
+			Value: 3,
+		},
+		&cli.BoolFlag{
+			Name:  "score-as-percent",
+			Usage: "Display scores as percentages (e.g. 87.3%)",
+		},
+		&cli.IntFlag{
+			Name:  "start-index",
+			Usage: "Number the first result in table output from this index (display only)",
+			Value: 1,
+		},
+	}
+}
+
+// extraFieldFlags set request fields the CLI doesn't model (see
+// parseExtraFields)
+func extraFieldFlags() []cli.Flag {
+	return []cli.Flag{
+		&rawStringSliceFlag{
+			Name:  "set",
+			Usage: "Set an extra request field as a string: key=value (repeatable, dots address nested fields)",
+		},
+		&rawStringSliceFlag{
+			Name:  "set-json",
+			Usage: "Set an extra request field from JSON: key=<json> (repeatable, dots address nested fields)",
+		},
+	}
+}
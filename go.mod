@@ -3,16 +3,20 @@ module github.com/12458/exa-cli
 go 1.25.6
 
 require (
+	github.com/fatih/color v1.18.0
+	github.com/itchyny/gojq v0.12.19
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/rodaine/table v1.3.0
 	github.com/toon-format/toon-go v0.0.0-20251202084852-7ca0e27c4e8c
 	github.com/urfave/cli/v3 v3.6.2
+	golang.org/x/term v0.39.0
+	golang.org/x/text v0.40.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/fatih/color v1.18.0 // indirect
+	github.com/itchyny/timefmt-go v0.1.8 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	golang.org/x/sys v0.40.0 // indirect
-	golang.org/x/term v0.39.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
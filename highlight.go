@@ -0,0 +1,69 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// ansiEscape matches terminal color escape sequences
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// visibleWidth is a table WidthFunc that ignores color escape sequences and
+// counts wide characters as two columns, so columns stay aligned when only
+// some cells are highlighted or titles are in CJK scripts
+func visibleWidth(s string) int {
+	return displayWidth(ansiEscape.ReplaceAllString(s, ""))
+}
+
+// termPattern builds a case-insensitive pattern matching the words of query,
+// skipping stopwords and words under three letters. Longer words are tried
+// first so a word containing another is matched whole. Returns nil if no
+// words qualify.
+func termPattern(query string) *regexp.Regexp {
+	var terms []string
+	for _, w := range titleWords(query) {
+		if len([]rune(w)) < 3 || relatedStopwords[w] || slices.Contains(terms, w) {
+			continue
+		}
+		terms = append(terms, w)
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	slices.SortFunc(terms, func(a, b string) int { return len(b) - len(a) })
+	for i, t := range terms {
+		terms[i] = regexp.QuoteMeta(t)
+	}
+	return regexp.MustCompile(`(?i)(?:` + strings.Join(terms, "|") + `)`)
+}
+
+// highlightTerms colors the matches of pattern in s. Adjacent matches are
+// merged into a single highlighted span. A nil pattern returns s unchanged.
+func highlightTerms(s string, pattern *regexp.Regexp) string {
+	if pattern == nil {
+		return s
+	}
+	matches := pattern.FindAllStringIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+
+	hl := color.New(color.FgYellow, color.Bold).SprintFunc()
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(matches); i++ {
+		start, end := matches[i][0], matches[i][1]
+		for i+1 < len(matches) && matches[i+1][0] == end {
+			i++
+			end = matches[i][1]
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(hl(s[start:end]))
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const cacheDir = "exa"
+
+// Cache namespaces. Each is a subdirectory of Dir holding one <sha256>.json
+// file per entry; Info and Clear only look at these.
+const (
+	// ContentsNamespace holds previously fetched page text, keyed by URL
+	ContentsNamespace = "contents"
+	// SeenNamespace holds result URLs already reported by --new-only, keyed
+	// by query
+	SeenNamespace = "seen"
+)
+
+var namespaces = []string{ContentsNamespace, SeenNamespace}
+
+// dirOverride replaces the default cache directory when set
+var dirOverride string
+
+// SetDir makes Dir return dir instead of the default location, as for the
+// --cache-dir flag.
+func SetDir(dir string) {
+	dirOverride = dir
+}
+
+// Dir returns the path to the cache directory: the one given to SetDir, or
+// ~/.cache/exa (honouring XDG_CACHE_HOME)
+func Dir() (string, error) {
+	if dirOverride != "" {
+		return dirOverride, nil
+	}
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cacheHome, cacheDir), nil
+}
+
+// entryPath returns the file path for key within namespace. Keys are hashed so
+// arbitrary strings (URLs, queries) map to safe file names.
+func entryPath(namespace, key string) (string, error) {
+	if !slices.Contains(namespaces, namespace) {
+		return "", fmt.Errorf("unknown cache namespace %q", namespace)
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, namespace, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// Load reads the cache entry for key in namespace into v.
+// Returns false (not an error) if the entry doesn't exist.
+func Load(namespace, key string, v any) (bool, error) {
+	path, err := entryPath(namespace, key)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse cache entry %s: %w", path, err)
+	}
+
+	return true, nil
+}
+
+// Save writes v as the cache entry for key in namespace.
+func Save(namespace, key string, v any) error {
+	path, err := entryPath(namespace, key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// Stats describes the entries in the cache directory
+type Stats struct {
+	Entries int
+	Bytes   int64
+}
+
+// Info walks the cache directory and totals its entries and their size.
+// A missing directory is an empty cache.
+func Info() (Stats, error) {
+	var stats Stats
+	err := walkEntries(func(path string, info fs.FileInfo) error {
+		stats.Entries++
+		stats.Bytes += info.Size()
+		return nil
+	})
+	return stats, err
+}
+
+// Clear removes cache entries last written more than olderThan ago, or every
+// entry if olderThan is zero, and returns what was removed
+func Clear(olderThan time.Duration) (Stats, error) {
+	var removed Stats
+	cutoff := time.Now().Add(-olderThan)
+	err := walkEntries(func(path string, info fs.FileInfo) error {
+		if olderThan > 0 && info.ModTime().After(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+		removed.Entries++
+		removed.Bytes += info.Size()
+		return nil
+	})
+	return removed, err
+}
+
+// walkEntries calls fn for every cache entry: the <sha256>.json files in the
+// namespace subdirectories that Save writes. Anything else in the cache
+// directory (other files, unknown subdirectories, symlinks) is left alone, so a
+// --cache-dir pointing somewhere unexpected can't lose unrelated files.
+func walkEntries(fn func(path string, info fs.FileInfo) error) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	for _, namespace := range namespaces {
+		nsDir := filepath.Join(dir, namespace)
+		if info, err := os.Lstat(nsDir); err != nil || !info.IsDir() {
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("failed to read cache directory: %w", err)
+			}
+			continue
+		}
+		entries, err := os.ReadDir(nsDir)
+		if err != nil {
+			return fmt.Errorf("failed to read cache directory: %w", err)
+		}
+		for _, d := range entries {
+			if !d.Type().IsRegular() || !isEntryName(d.Name()) {
+				continue
+			}
+			info, err := d.Info()
+			if err != nil {
+				return fmt.Errorf("failed to read cache directory: %w", err)
+			}
+			if err := fn(filepath.Join(nsDir, d.Name()), info); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isEntryName reports whether name is a file name entryPath produces: a hex
+// SHA-256 followed by .json
+func isEntryName(name string) bool {
+	hash, ok := strings.CutSuffix(name, ".json")
+	if !ok || len(hash) != hex.EncodedLen(sha256.Size) {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil && strings.ToLower(hash) == hash
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClearOnlyRemovesEntries(t *testing.T) {
+	dir := t.TempDir()
+	SetDir(dir)
+	t.Cleanup(func() { SetDir("") })
+
+	for _, ns := range namespaces {
+		if err := Save(ns, "https://example.com/", map[string]string{"k": "v"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Files Save never writes, which Clear must leave alone
+	strays := []string{
+		"notes.txt",
+		"other/data.json",
+		filepath.Join(ContentsNamespace, "README.json"),
+		filepath.Join(ContentsNamespace, "keep.txt"),
+	}
+	for _, name := range strays {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != len(namespaces) {
+		t.Errorf("Info counted %d entries, want %d", stats.Entries, len(namespaces))
+	}
+
+	removed, err := Clear(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed.Entries != len(namespaces) {
+		t.Errorf("Clear removed %d entries, want %d", removed.Entries, len(namespaces))
+	}
+	for _, name := range strays {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Clear removed %s: %v", name, err)
+		}
+	}
+	var v map[string]string
+	if found, _ := Load(ContentsNamespace, "https://example.com/", &v); found {
+		t.Error("entry still present after Clear")
+	}
+}
+
+func TestClearOlderThan(t *testing.T) {
+	SetDir(t.TempDir())
+	t.Cleanup(func() { SetDir("") })
+
+	if err := Save(SeenNamespace, "old", []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(SeenNamespace, "new", []string{"b"}); err != nil {
+		t.Fatal(err)
+	}
+	old, _ := entryPath(SeenNamespace, "old")
+	past := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Clear(24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed.Entries != 1 {
+		t.Errorf("removed %d entries, want 1", removed.Entries)
+	}
+	var v []string
+	if found, _ := Load(SeenNamespace, "new", &v); !found {
+		t.Error("recent entry was removed")
+	}
+}
+
+func TestClearMissingDirectory(t *testing.T) {
+	SetDir(filepath.Join(t.TempDir(), "missing"))
+	t.Cleanup(func() { SetDir("") })
+
+	removed, err := Clear(0)
+	if err != nil || removed.Entries != 0 {
+		t.Errorf("got %+v, %v for a missing cache directory, want an empty result", removed, err)
+	}
+}
+
+func TestSaveRejectsUnknownNamespace(t *testing.T) {
+	SetDir(t.TempDir())
+	t.Cleanup(func() { SetDir("") })
+
+	if err := Save("../escape", "k", 1); err == nil {
+		t.Error("Save accepted an unknown namespace")
+	}
+}
@@ -3,25 +3,306 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	mathrand "math/rand/v2"
 	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	baseURL   = "https://api.exa.ai"
 	apiKeyEnv = "EXA_API_KEY"
+
+	// MaxContentsIDs is the maximum number of IDs accepted by a single
+	// /contents request. Larger requests must be split into batches.
+	MaxContentsIDs = 100
+
+	// MaxSearchResults is the maximum numResults accepted by a single
+	// /search request. Larger searches must be paginated.
+	MaxSearchResults = 100
+
+	// maxRetryDelay caps the wait before a retry, whether computed by
+	// backoff or requested by a Retry-After header
+	maxRetryDelay = time.Minute
+)
+
+// Backoff is a strategy for the wait between retries, given the base
+// backoff b and the attempt number n (from 0)
+type Backoff string
+
+const (
+	// BackoffFullJitter waits a random time between 0 and b*2^n, spreading
+	// out concurrent clients the most
+	BackoffFullJitter Backoff = "full-jitter"
+	// BackoffEqualJitter waits between b*2^n/2 and b*2^n, always leaving some
+	// gap between attempts
+	BackoffEqualJitter Backoff = "equal-jitter"
+	// BackoffExponential waits exactly b*2^n
+	BackoffExponential Backoff = "exponential"
+	// BackoffConstant waits b before every retry
+	BackoffConstant Backoff = "constant"
 )
 
+// Backoffs lists the backoff strategies, the default first
+var Backoffs = []Backoff{BackoffFullJitter, BackoffEqualJitter, BackoffExponential, BackoffConstant}
+
+// StatusError is returned when the API responds with an HTTP error status
+type StatusError struct {
+	StatusCode int
+	Message    string
+
+	// retryAfter is the wait requested by the response's Retry-After
+	// header, zero if absent
+	retryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API error (%d): %s", e.StatusCode, e.Message)
+}
+
+// NetworkError is returned when a request can't be sent or its response
+// can't be read: DNS, connection and TLS failures, and attempt timeouts
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// IsFatal reports whether err is an API error that will fail the same way for
+// any request made with this client (authentication, permissions, or request
+// validation), so sending further requests is pointless.
+func IsFatal(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	switch statusErr.StatusCode {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether a response with this status may succeed if the
+// request is sent again: rate limiting and server errors
+func retryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// parseRetryAfter reads a Retry-After header given as seconds or an HTTP
+// date. It returns zero if the header is absent or malformed.
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// RequestHook can modify an outgoing request before it is sent. body is the
+// encoded request body (nil for requests without one).
+type RequestHook func(req *http.Request, body []byte) error
+
+// HMACSigner returns a RequestHook that sets header to the hex-encoded
+// HMAC-SHA256 of the request body, keyed with secret.
+func HMACSigner(secret, header string) RequestHook {
+	return func(req *http.Request, body []byte) error {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set(header, hex.EncodeToString(mac.Sum(nil)))
+		return nil
+	}
+}
+
+// RateLimit is the request quota reported by the API's rate limit headers
+type RateLimit struct {
+	Remaining int
+	Reset     time.Duration // time until the quota resets, zero if unknown
+}
+
+// parseRateLimit reads X-RateLimit-Remaining and X-RateLimit-Reset. Reset may
+// be a number of seconds or a Unix timestamp. ok is false if the remaining
+// count is absent or malformed.
+func parseRateLimit(h http.Header, now time.Time) (rl RateLimit, ok bool) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	rl.Remaining = remaining
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil && reset > 0 {
+		// Values this large are timestamps rather than durations
+		if reset > 1_000_000_000 {
+			rl.Reset = max(time.Unix(reset, 0).Sub(now), 0).Round(time.Second)
+		} else {
+			rl.Reset = time.Duration(reset) * time.Second
+		}
+	}
+	return rl, true
+}
+
 type Client struct {
 	apiKey     string
 	baseURL    string
 	httpClient *http.Client
+	logger     *slog.Logger
+
+	// timeout and tlsConfig are applied to a copy of httpClient by New, so
+	// a caller's *http.Client is never modified and options can come in any
+	// order. hasTimeout tells a zero timeout apart from an unset one.
+	timeout    time.Duration
+	hasTimeout bool
+	tlsConfig  *tls.Config
+
+	attemptTimeout time.Duration
+	maxRetries     int
+	retryBackoff   time.Duration
+	backoffKind    Backoff
+	retryLog       io.Writer
+	idempotency    bool
+	hooks          []RequestHook
+
+	// sem bounds the number of requests in flight across all goroutines
+	sem chan struct{}
+
+	mu           sync.Mutex
+	rateLimit    RateLimit
+	hasRateLimit bool
+}
+
+// ClientOption configures a Client. Options are applied in order by New.
+type ClientOption func(*Client)
+
+// WithBaseURL sends requests to url instead of the public Exa API.
+func WithBaseURL(url string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithHTTPClient sends requests through a copy of hc. hc itself is never
+// modified by other options such as WithTimeout and WithTLSConfig.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTimeout bounds each HTTP attempt of a request, including reading the
+// response. Zero means no limit.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = d
+		c.hasTimeout = true
+	}
+}
+
+// WithLogger sets the logger used for diagnostic output such as request timing.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithAttemptTimeout bounds each individual HTTP attempt, independently of any
+// deadline on the context passed to a request. Zero means no per-attempt limit.
+func WithAttemptTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.attemptTimeout = d
+	}
+}
+
+// WithRetries retries requests that fail with 429 or a 5xx status up to
+// maxRetries times. The wait is computed from backoff by the strategy set
+// with WithBackoff, full jitter by default, unless a 429 response says how
+// long to wait in a Retry-After header. Zero retries disables retrying.
+func WithRetries(maxRetries int, backoff time.Duration) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = max(maxRetries, 0)
+		c.retryBackoff = backoff
+	}
+}
+
+// WithBackoff sets the strategy for the wait between retries.
+func WithBackoff(kind Backoff) ClientOption {
+	return func(c *Client) {
+		c.backoffKind = kind
+	}
+}
+
+// WithRetryLog writes a line to w before each retry, with the attempt
+// number, the status that caused it and the wait, e.g.
+// "retry: POST /search attempt 2/4 after 429, waiting 1.2s".
+func WithRetryLog(w io.Writer) ClientOption {
+	return func(c *Client) {
+		c.retryLog = w
+	}
+}
+
+// WithIdempotency enables sending an Idempotency-Key header. The key is
+// generated once per logical request and shared by all of its attempts.
+func WithIdempotency(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.idempotency = enabled
+	}
+}
+
+// WithTLSConfig replaces the TLS settings used for connections to the API, for
+// example to trust a private CA. Proxy settings from the environment, or the
+// other settings of a WithHTTPClient transport, are kept.
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithConcurrencyLimit caps how many requests may be in flight at once, across
+// every caller sharing the client. Zero or less means no limit.
+func WithConcurrencyLimit(n int) ClientOption {
+	return func(c *Client) {
+		if n <= 0 {
+			c.sem = nil
+			return
+		}
+		c.sem = make(chan struct{}, n)
+	}
+}
+
+// WithRequestHook registers a hook run on every outgoing request, after the
+// standard headers are set. Hooks run in the order they are added.
+func WithRequestHook(hook RequestHook) ClientOption {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, hook)
+	}
 }
 
-func New(apiKey string) (*Client, error) {
+// New creates a client for apiKey, falling back to EXA_API_KEY if it is empty.
+func New(apiKey string, opts ...ClientOption) (*Client, error) {
 	if apiKey == "" {
 		apiKey = os.Getenv(apiKeyEnv)
 	}
@@ -29,24 +310,157 @@ func New(apiKey string) (*Client, error) {
 		return nil, fmt.Errorf("API key required. Set EXA_API_KEY env var, use --api-key flag, or run 'exa configure'. Get your key at https://dashboard.exa.ai/api-keys")
 	}
 
-	return &Client{
-		apiKey:     apiKey,
-		baseURL:    baseURL,
-		httpClient: &http.Client{},
-	}, nil
+	c := &Client{
+		apiKey:      apiKey,
+		baseURL:     baseURL,
+		httpClient:  &http.Client{},
+		logger:      slog.New(slog.DiscardHandler),
+		backoffKind: BackoffFullJitter,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if !slices.Contains(Backoffs, c.backoffKind) {
+		return nil, fmt.Errorf("unknown backoff strategy %q", c.backoffKind)
+	}
+
+	hc := *c.httpClient
+	if c.hasTimeout {
+		hc.Timeout = c.timeout
+	}
+	if c.tlsConfig != nil {
+		var transport *http.Transport
+		switch t := hc.Transport.(type) {
+		case nil:
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+		case *http.Transport:
+			transport = t.Clone()
+		default:
+			return nil, fmt.Errorf("can't set TLS config on a %T transport", hc.Transport)
+		}
+		transport.TLSClientConfig = c.tlsConfig
+		hc.Transport = transport
+	}
+	c.httpClient = &hc
+	return c, nil
+}
+
+// RateLimit returns the quota reported by the most recent response that
+// carried rate limit headers. ok is false if none has.
+func (c *Client) RateLimit() (rl RateLimit, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rateLimit, c.hasRateLimit
 }
 
+// doRequest sends a request, retrying it as configured by WithRetries. The
+// body is encoded once and the same idempotency key is sent with every
+// attempt.
 func (c *Client) doRequest(ctx context.Context, method, path string, body any, result any) error {
-	var reqBody io.Reader
+	var idempotencyKey string
+	if c.idempotency {
+		idempotencyKey = rand.Text()
+	}
+
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		var err error
+		jsonBody, err = json.Marshal(body)
 		if err != nil {
 			return fmt.Errorf("failed to marshal request: %w", err)
 		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.doAttempt(ctx, method, path, jsonBody, idempotencyKey, result)
+		var statusErr *StatusError
+		if err == nil || attempt == c.maxRetries || !errors.As(err, &statusErr) || !retryable(statusErr.StatusCode) {
+			return err
+		}
+
+		delay := c.backoff(attempt)
+		if statusErr.StatusCode == http.StatusTooManyRequests && statusErr.retryAfter > 0 {
+			delay = min(statusErr.retryAfter, maxRetryDelay)
+		}
+		c.logger.Debug("retrying request", "method", method, "path", path, "status", statusErr.StatusCode, "attempt", attempt+1, "delay", delay)
+		if c.retryLog != nil {
+			fmt.Fprintf(c.retryLog, "retry: %s %s attempt %d/%d after %d, waiting %s\n", method, path, attempt+2, c.maxRetries+1, statusErr.StatusCode, roundDelay(delay))
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// backoff returns the wait before retry number attempt+1 under the client's
+// strategy. The exponential strategies double the base for each previous
+// attempt; the jittered ones randomize all or the upper half of that so
+// concurrent clients don't retry in lockstep. Waits are capped at
+// maxRetryDelay, and a zero base retries without waiting.
+func (c *Client) backoff(attempt int) time.Duration {
+	if c.retryBackoff <= 0 {
+		return 0
+	}
+	if c.backoffKind == BackoffConstant {
+		return min(c.retryBackoff, maxRetryDelay)
+	}
+	// A negative result means the shift overflowed
+	d := c.retryBackoff << min(attempt, 30)
+	if d < 0 || d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	switch c.backoffKind {
+	case BackoffExponential:
+		return d
+	case BackoffEqualJitter:
+		half := d / 2
+		return half + mathrand.N(d-half+1)
+	default:
+		return mathrand.N(d + 1)
+	}
+}
+
+// roundDelay rounds d for display: to the millisecond below a second, and to
+// a tenth of a second above
+func roundDelay(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(100 * time.Millisecond)
+}
+
+// doAttempt makes a single HTTP attempt of a request. jsonBody is nil for
+// requests without a body.
+func (c *Client) doAttempt(ctx context.Context, method, path string, jsonBody []byte, idempotencyKey string, result any) error {
+	if c.sem != nil {
+		select {
+		case c.sem <- struct{}{}:
+			defer func() { <-c.sem }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// The reader is consumed by each attempt, so it's created afresh here
+	var reqBody io.Reader
+	if jsonBody != nil {
 		reqBody = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	// Each attempt gets its own deadline derived from the caller's context
+	attemptCtx := ctx
+	if c.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, c.attemptTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(attemptCtx, method, c.baseURL+path, reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -54,24 +468,62 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body any, r
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("x-api-key", c.apiKey)
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	for _, hook := range c.hooks {
+		if err := hook(req, jsonBody); err != nil {
+			return fmt.Errorf("request hook failed: %w", err)
+		}
+	}
+
+	c.logger.Debug("sending request", "method", method, "path", path)
+	start := time.Now()
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		c.logger.Debug("request failed", "method", method, "path", path, "elapsed", time.Since(start), "error", err)
+		if errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return &NetworkError{fmt.Errorf("request attempt timed out after %s", c.attemptTimeout)}
+		}
+		return &NetworkError{fmt.Errorf("request failed: %w", err)}
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	c.logger.Debug("received response",
+		"method", method,
+		"path", path,
+		"status", resp.StatusCode,
+		"elapsed", time.Since(start),
+		"request_id", resp.Header.Get("x-request-id"),
+	)
+
+	if rl, ok := parseRateLimit(resp.Header, time.Now()); ok {
+		c.mu.Lock()
+		c.rateLimit, c.hasRateLimit = rl, true
+		c.mu.Unlock()
+		c.logger.Debug("rate limit", "remaining", rl.Remaining, "resets_in", rl.Reset)
+	}
+
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		if errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return &NetworkError{fmt.Errorf("request attempt timed out after %s while reading response", c.attemptTimeout)}
+		}
+		return &NetworkError{fmt.Errorf("failed to read response: %w", err)}
 	}
 
 	if resp.StatusCode >= 400 {
+		statusErr := &StatusError{
+			StatusCode: resp.StatusCode,
+			Message:    string(respBody),
+			retryAfter: parseRetryAfter(resp.Header, time.Now()),
+		}
 		var apiErr APIError
 		if err := json.Unmarshal(respBody, &apiErr); err == nil && apiErr.Error != "" {
-			return fmt.Errorf("API error (%d): %s", resp.StatusCode, apiErr.Error)
+			statusErr.Message = apiErr.Error
 		}
-		return fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return statusErr
 	}
 
 	if result != nil {
@@ -83,10 +535,100 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body any, r
 	return nil
 }
 
+// withExtraFields returns body with fields merged into its JSON object. Keys may
+// use dots to address nested objects (e.g. "contents.livecrawl"). If fields is
+// empty, body is returned unchanged.
+func withExtraFields(body any, fields map[string]any) (any, error) {
+	if len(fields) == 0 {
+		return body, nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	var merged map[string]any
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	for key, value := range fields {
+		parts := strings.Split(key, ".")
+		obj := merged
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := obj[part].(map[string]any)
+			if !ok {
+				if _, exists := obj[part]; exists && obj[part] != nil {
+					return nil, fmt.Errorf("cannot set %q: %q is not an object", key, part)
+				}
+				next = map[string]any{}
+				obj[part] = next
+			}
+			obj = next
+		}
+		obj[parts[len(parts)-1]] = value
+	}
+
+	return merged, nil
+}
+
+// Body returns the JSON request body sent for req, with ExtraFields merged in
+func (req *SearchRequest) Body() (any, error) {
+	return withExtraFields(req, req.ExtraFields)
+}
+
+// Body returns the JSON request body sent for req, with ExtraFields merged in
+func (req *FindSimilarRequest) Body() (any, error) {
+	return withExtraFields(req, req.ExtraFields)
+}
+
+// Body returns the JSON request body sent for req, with ExtraFields merged in
+func (req *ContentsRequest) Body() (any, error) {
+	return withExtraFields(req, req.ExtraFields)
+}
+
+// Body returns the JSON request body sent for req
+func (req *AnswerRequest) Body() (any, error) {
+	return req, nil
+}
+
 // Search performs a web search using Exa
 func (c *Client) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	body, err := req.Body()
+	if err != nil {
+		return nil, err
+	}
+
+	var result SearchResponse
+	if err := c.doRequest(ctx, http.MethodPost, "/search", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// FindSimilar finds pages similar to a URL. The response has the same shape as
+// a search response.
+func (c *Client) FindSimilar(ctx context.Context, req *FindSimilarRequest) (*SearchResponse, error) {
+	body, err := req.Body()
+	if err != nil {
+		return nil, err
+	}
+
 	var result SearchResponse
-	if err := c.doRequest(ctx, http.MethodPost, "/search", req, &result); err != nil {
+	if err := c.doRequest(ctx, http.MethodPost, "/findSimilar", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Answer asks for an answer to a natural-language question, with citations
+func (c *Client) Answer(ctx context.Context, req *AnswerRequest) (*AnswerResponse, error) {
+	if req.Stream {
+		return nil, fmt.Errorf("streaming answers are not supported")
+	}
+
+	var result AnswerResponse
+	if err := c.doRequest(ctx, http.MethodPost, "/answer", req, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -94,8 +636,17 @@ func (c *Client) Search(ctx context.Context, req *SearchRequest) (*SearchRespons
 
 // GetContents retrieves content from URLs
 func (c *Client) GetContents(ctx context.Context, req *ContentsRequest) (*ContentsResponse, error) {
+	if len(req.IDs) > MaxContentsIDs {
+		return nil, fmt.Errorf("too many URLs (%d): the contents endpoint accepts at most %d per request, use --batch-size to split them", len(req.IDs), MaxContentsIDs)
+	}
+
+	body, err := req.Body()
+	if err != nil {
+		return nil, err
+	}
+
 	var result ContentsResponse
-	if err := c.doRequest(ctx, http.MethodPost, "/contents", req, &result); err != nil {
+	if err := c.doRequest(ctx, http.MethodPost, "/contents", body, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
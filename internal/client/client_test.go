@@ -0,0 +1,357 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client for the server at url with the given
+// options
+func newTestClient(t *testing.T, url string, opts ...ClientOption) *Client {
+	t.Helper()
+	c, err := New("test-key", append([]ClientOption{WithBaseURL(url)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// recordingServer serves the responses in order, one per request, repeating
+// the last one, and records each request body
+type recordingServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	bodies []string
+}
+
+type cannedResponse struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+func newRecordingServer(t *testing.T, responses ...cannedResponse) *recordingServer {
+	t.Helper()
+	s := &recordingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, string(body))
+		resp := responses[min(len(s.bodies), len(responses))-1]
+		s.mu.Unlock()
+
+		for k, v := range resp.header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(resp.status)
+		_, _ = io.WriteString(w, resp.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// requests returns the bodies of the requests received so far
+func (s *recordingServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.bodies...)
+}
+
+var okSearch = cannedResponse{status: http.StatusOK, body: `{"results":[{"title":"T","url":"https://example.com/","id":"1"}]}`}
+
+func TestRetrySucceedsAfterServerErrors(t *testing.T) {
+	srv := newRecordingServer(t,
+		cannedResponse{status: http.StatusBadGateway, body: `{"error":"bad gateway"}`},
+		cannedResponse{status: http.StatusServiceUnavailable, body: `{"error":"unavailable"}`},
+		okSearch,
+	)
+	c := newTestClient(t, srv.URL, WithRetries(3, time.Millisecond))
+
+	resp, err := c.Search(context.Background(), &SearchRequest{Query: "retry me", NumResults: 1})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(resp.Results) != 1 {
+		t.Errorf("got %d results, want 1", len(resp.Results))
+	}
+
+	bodies := srv.requests()
+	if len(bodies) != 3 {
+		t.Fatalf("got %d attempts, want 3", len(bodies))
+	}
+	// The body reader is consumed by each attempt, so every retry must
+	// send the full body again
+	for i, body := range bodies {
+		if body != bodies[0] || body == "" {
+			t.Errorf("attempt %d sent body %q, want %q", i+1, body, bodies[0])
+		}
+	}
+}
+
+func TestRetryGivesUpAfterMaxRetries(t *testing.T) {
+	srv := newRecordingServer(t, cannedResponse{status: http.StatusInternalServerError, body: `{"error":"boom"}`})
+	c := newTestClient(t, srv.URL, WithRetries(2, time.Millisecond))
+
+	_, err := c.Search(context.Background(), &SearchRequest{Query: "q"})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("got error %v, want a 500 StatusError", err)
+	}
+	if n := len(srv.requests()); n != 3 {
+		t.Errorf("got %d attempts, want 3 (1 + 2 retries)", n)
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	srv := newRecordingServer(t,
+		cannedResponse{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "1"}, body: `{"error":"slow down"}`},
+		okSearch,
+	)
+	// The backoff alone would retry almost immediately
+	c := newTestClient(t, srv.URL, WithRetries(1, time.Millisecond))
+
+	start := time.Now()
+	if _, err := c.Search(context.Background(), &SearchRequest{Query: "q"}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if waited := time.Since(start); waited < 900*time.Millisecond {
+		t.Errorf("retried after %s, want about 1s from Retry-After", waited)
+	}
+	if n := len(srv.requests()); n != 2 {
+		t.Errorf("got %d attempts, want 2", n)
+	}
+}
+
+func TestRetryStopsWhenContextCanceledDuringBackoff(t *testing.T) {
+	srv := newRecordingServer(t, cannedResponse{status: http.StatusServiceUnavailable, body: `{"error":"unavailable"}`})
+	c := newTestClient(t, srv.URL, WithRetries(3, time.Minute), WithBackoff(BackoffConstant))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := c.Search(ctx, &SearchRequest{Query: "q"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("returned after %s, want promptly after cancellation", waited)
+	}
+	if n := len(srv.requests()); n != 1 {
+		t.Errorf("got %d attempts, want 1", n)
+	}
+}
+
+func TestNoRetryOnClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			srv := newRecordingServer(t, cannedResponse{status: status, body: `{"error":"no"}`})
+			c := newTestClient(t, srv.URL, WithRetries(3, time.Millisecond))
+
+			_, err := c.Search(context.Background(), &SearchRequest{Query: "q"})
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != status {
+				t.Fatalf("got error %v, want a %d StatusError", err, status)
+			}
+			if n := len(srv.requests()); n != 1 {
+				t.Errorf("got %d attempts, want 1", n)
+			}
+		})
+	}
+}
+
+// failingTransport fails every request without reaching a server
+type failingTransport struct {
+	mu       sync.Mutex
+	attempts int
+}
+
+func (f *failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	return nil, errors.New("connection refused")
+}
+
+func TestNoRetryOnNetworkErrors(t *testing.T) {
+	transport := &failingTransport{}
+	c := newTestClient(t, "http://exa.invalid",
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithRetries(3, time.Millisecond),
+	)
+
+	_, err := c.Search(context.Background(), &SearchRequest{Query: "q"})
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) {
+		t.Fatalf("got error %v, want a NetworkError", err)
+	}
+	if transport.attempts != 1 {
+		t.Errorf("got %d attempts, want 1", transport.attempts)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.value != "" {
+			h.Set("Retry-After", tt.value)
+		}
+		if got := parseRetryAfter(h, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestOptionsDontModifyCallerHTTPClient(t *testing.T) {
+	transport := &http.Transport{}
+	hc := &http.Client{Transport: transport, Timeout: time.Minute}
+	cfg := &tls.Config{ServerName: "api.example.com"}
+
+	// Timeout and TLS options apply whichever side of WithHTTPClient they
+	// come on
+	for _, opts := range [][]ClientOption{
+		{WithHTTPClient(hc), WithTimeout(time.Second), WithTLSConfig(cfg)},
+		{WithTimeout(time.Second), WithTLSConfig(cfg), WithHTTPClient(hc)},
+	} {
+		c := newTestClient(t, "http://exa.invalid", opts...)
+		if c.httpClient == hc {
+			t.Fatal("client uses the caller's *http.Client")
+		}
+		if c.httpClient.Timeout != time.Second {
+			t.Errorf("got timeout %s, want 1s", c.httpClient.Timeout)
+		}
+		got, ok := c.httpClient.Transport.(*http.Transport)
+		if !ok || got == transport || got.TLSClientConfig != cfg {
+			t.Errorf("got transport %#v, want a clone of the caller's with the TLS config", c.httpClient.Transport)
+		}
+	}
+
+	// Transport.Clone sets up HTTP/2 on the original, so only check that the
+	// caller's TLS config wasn't replaced with ours
+	if hc.Timeout != time.Minute || hc.Transport != transport || transport.TLSClientConfig == cfg {
+		t.Errorf("caller's client was modified: %+v", hc)
+	}
+}
+
+func TestTimeoutKeepsCallerTimeoutWhenUnset(t *testing.T) {
+	c := newTestClient(t, "http://exa.invalid", WithHTTPClient(&http.Client{Timeout: time.Minute}))
+	if c.httpClient.Timeout != time.Minute {
+		t.Errorf("got timeout %s, want the caller's 1m", c.httpClient.Timeout)
+	}
+}
+
+func TestTLSConfigRejectsCustomTransport(t *testing.T) {
+	_, err := New("test-key", WithHTTPClient(&http.Client{Transport: &failingTransport{}}), WithTLSConfig(&tls.Config{}))
+	if err == nil {
+		t.Error("got nil error for a TLS config on a custom transport")
+	}
+}
+
+func TestRetryLog(t *testing.T) {
+	srv := newRecordingServer(t,
+		cannedResponse{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "0"}, body: `{"error":"slow down"}`},
+		cannedResponse{status: http.StatusBadGateway, body: `{"error":"bad gateway"}`},
+		okSearch,
+	)
+	var log strings.Builder
+	c := newTestClient(t, srv.URL, WithRetries(3, time.Millisecond), WithRetryLog(&log))
+
+	if _, err := c.Search(context.Background(), &SearchRequest{Query: "q"}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(log.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want 2: %q", len(lines), log.String())
+	}
+	for i, want := range []string{"retry: POST /search attempt 2/4 after 429, waiting ", "retry: POST /search attempt 3/4 after 502, waiting "} {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("line %d is %q, want it to start with %q", i+1, lines[i], want)
+		}
+	}
+}
+
+func TestRoundDelay(t *testing.T) {
+	tests := []struct {
+		d, want time.Duration
+	}{
+		{1234567 * time.Microsecond, 1200 * time.Millisecond},
+		{345678 * time.Microsecond, 346 * time.Millisecond},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		if got := roundDelay(tt.d); got != tt.want {
+			t.Errorf("roundDelay(%s) = %s, want %s", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	tests := []struct {
+		kind Backoff
+		// lower and upper bounds of the wait before retry attempt+1
+		bounds func(base time.Duration, attempt int) (lo, hi time.Duration)
+	}{
+		{BackoffFullJitter, func(b time.Duration, n int) (time.Duration, time.Duration) { return 0, b << n }},
+		{BackoffEqualJitter, func(b time.Duration, n int) (time.Duration, time.Duration) { return (b << n) / 2, b << n }},
+		{BackoffExponential, func(b time.Duration, n int) (time.Duration, time.Duration) { return b << n, b << n }},
+		{BackoffConstant, func(b time.Duration, _ int) (time.Duration, time.Duration) { return b, b }},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			// A zero base means retrying without waiting
+			for _, base := range []time.Duration{100 * time.Millisecond, 0} {
+				c := newTestClient(t, "http://exa.invalid", WithRetries(5, base), WithBackoff(tt.kind))
+				for attempt := range 5 {
+					lo, hi := tt.bounds(base, attempt)
+					for range 200 {
+						if d := c.backoff(attempt); d < lo || d > hi {
+							t.Fatalf("base %s: attempt %d waited %s, want between %s and %s", base, attempt, d, lo, hi)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffCapped(t *testing.T) {
+	for _, kind := range Backoffs {
+		c := newTestClient(t, "http://exa.invalid", WithRetries(40, 10*time.Minute), WithBackoff(kind))
+		for _, attempt := range []int{0, 1, 35} {
+			if d := c.backoff(attempt); d > maxRetryDelay {
+				t.Errorf("%s: attempt %d waited %s, want at most %s", kind, attempt, d, maxRetryDelay)
+			}
+		}
+	}
+}
+
+func TestBackoffDefaultsToFullJitter(t *testing.T) {
+	c := newTestClient(t, "http://exa.invalid")
+	if c.backoffKind != BackoffFullJitter {
+		t.Errorf("got default backoff %q, want %q", c.backoffKind, BackoffFullJitter)
+	}
+	if _, err := New("test-key", WithBackoff("linear")); err == nil {
+		t.Error("New accepted an unknown backoff strategy")
+	}
+}
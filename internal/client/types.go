@@ -1,5 +1,11 @@
 package client
 
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
 // APIError represents an error response from the Exa API
 type APIError struct {
 	Error string `json:"error"`
@@ -23,11 +29,19 @@ type ContextOptions struct {
 	MaxCharacters int `json:"maxCharacters,omitempty"`
 }
 
+// ExtrasOptions requests additional data extracted from each page
+type ExtrasOptions struct {
+	ImageLinks int `json:"imageLinks,omitempty"` // number of image URLs to return per page
+	Links      int `json:"links,omitempty"`      // number of links to return per page
+}
+
 // ContentsOptions specifies what content to retrieve
 type ContentsOptions struct {
-	Text       any `json:"text,omitempty"`       // bool or TextOptions
-	Highlights any `json:"highlights,omitempty"` // bool
-	Summary    any `json:"summary,omitempty"`    // bool or SummaryOptions
+	Text       any            `json:"text,omitempty"`       // bool or TextOptions
+	Highlights any            `json:"highlights,omitempty"` // bool
+	Summary    any            `json:"summary,omitempty"`    // bool or SummaryOptions
+	Metadata   bool           `json:"metadata,omitempty"`
+	Extras     *ExtrasOptions `json:"extras,omitempty"`
 }
 
 // SearchRequest represents a search API request
@@ -42,53 +56,165 @@ type SearchRequest struct {
 	EndPublishedDate   string           `json:"endPublishedDate,omitempty"`
 	Category           string           `json:"category,omitempty"`
 	MaxAgeHours        *int             `json:"maxAgeHours,omitempty"`
+
+	// ExtraFields are merged into the JSON body before sending, for API
+	// parameters not modeled above.
+	ExtraFields map[string]any `json:"-"`
+}
+
+// FindSimilarRequest represents a find-similar API request
+type FindSimilarRequest struct {
+	URL                string           `json:"url"`
+	NumResults         int              `json:"numResults,omitempty"`
+	Contents           *ContentsOptions `json:"contents,omitempty"`
+	IncludeDomains     []string         `json:"includeDomains,omitempty"`
+	ExcludeDomains     []string         `json:"excludeDomains,omitempty"`
+	StartPublishedDate string           `json:"startPublishedDate,omitempty"`
+	EndPublishedDate   string           `json:"endPublishedDate,omitempty"`
+
+	// ExtraFields are merged into the JSON body before sending, for API
+	// parameters not modeled above.
+	ExtraFields map[string]any `json:"-"`
 }
 
 // ContentsRequest represents a contents API request
 type ContentsRequest struct {
-	IDs              []string `json:"ids"`
-	Text             any      `json:"text,omitempty"`       // bool or TextOptions
-	Highlights       any      `json:"highlights,omitempty"` // bool
-	Summary          any      `json:"summary,omitempty"`    // bool or SummaryOptions
-	Context          any      `json:"context,omitempty"`    // bool or ContextOptions
-	Subpages         int      `json:"subpages,omitempty"`
-	SubpageTarget    []string `json:"subpageTarget,omitempty"`
-	MaxAgeHours      *int     `json:"maxAgeHours,omitempty"`
-	LivecrawlTimeout int      `json:"livecrawlTimeout,omitempty"`
+	IDs              []string       `json:"ids"`
+	Text             any            `json:"text,omitempty"`       // bool or TextOptions
+	Highlights       any            `json:"highlights,omitempty"` // bool
+	Summary          any            `json:"summary,omitempty"`    // bool or SummaryOptions
+	Context          any            `json:"context,omitempty"`    // bool or ContextOptions
+	Subpages         int            `json:"subpages,omitempty"`
+	SubpageTarget    []string       `json:"subpageTarget,omitempty"`
+	MaxAgeHours      *int           `json:"maxAgeHours,omitempty"`
+	Livecrawl        string         `json:"livecrawl,omitempty"` // never, fallback, preferred, always
+	Metadata         bool           `json:"metadata,omitempty"`
+	LivecrawlTimeout int            `json:"livecrawlTimeout,omitempty"`
+	Extras           *ExtrasOptions `json:"extras,omitempty"`
+
+	// ExtraFields are merged into the JSON body before sending, for API
+	// parameters not modeled above.
+	ExtraFields map[string]any `json:"-"`
 }
 
 // SearchResult represents a single search result
 type SearchResult struct {
-	Title         string   `json:"title" toon:"title"`
-	URL           string   `json:"url" toon:"url"`
-	PublishedDate string   `json:"publishedDate,omitempty" toon:"publishedDate,omitempty"`
-	Author        string   `json:"author,omitempty" toon:"author,omitempty"`
-	Score         float64  `json:"score,omitempty" toon:"score,omitempty"`
-	ID            string   `json:"id" toon:"id"`
-	Text          string   `json:"text,omitempty" toon:"text,omitempty"`
-	Highlights    []string `json:"highlights,omitempty" toon:"highlights,omitempty"`
-	Summary       string   `json:"summary,omitempty" toon:"summary,omitempty"`
+	Title         string        `json:"title" toon:"title"`
+	URL           string        `json:"url" toon:"url"`
+	PublishedDate string        `json:"publishedDate,omitempty" toon:"publishedDate,omitempty"`
+	Author        string        `json:"author,omitempty" toon:"author,omitempty"`
+	Score         float64       `json:"score,omitempty" toon:"score,omitempty"`
+	ID            string        `json:"id" toon:"id"`
+	Text          string        `json:"text,omitempty" toon:"text,omitempty"`
+	Highlights    []string      `json:"highlights,omitempty" toon:"highlights,omitempty"`
+	Summary       string        `json:"summary,omitempty" toon:"summary,omitempty"`
+	Image         string        `json:"image,omitempty" toon:"image,omitempty"`
+	Extras        *Extras       `json:"extras,omitempty" toon:"extras,omitempty"`
+	Metadata      *PageMetadata `json:"metadata,omitempty" toon:"metadata,omitempty"`
+
+	// Extra holds response fields this struct doesn't model, so new API
+	// fields still reach the output
+	Extra map[string]any `json:"_extra,omitempty" toon:"_extra,omitempty"`
+}
+
+// searchResultFields are the JSON names of the fields SearchResult models
+var searchResultFields = func() map[string]bool {
+	t := reflect.TypeFor[SearchResult]()
+	fields := make(map[string]bool, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+	}
+	return fields
+}()
+
+// UnmarshalJSON decodes the modeled fields as usual and collects any others
+// into Extra
+func (r *SearchResult) UnmarshalJSON(data []byte) error {
+	type plain SearchResult
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	var all map[string]any
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for k, v := range all {
+		if searchResultFields[k] {
+			continue
+		}
+		if r.Extra == nil {
+			r.Extra = make(map[string]any)
+		}
+		r.Extra[k] = v
+	}
+	return nil
+}
+
+// PageMetadata is structured metadata extracted from a page, such as its
+// OpenGraph tags
+type PageMetadata struct {
+	Title       string `json:"title,omitempty" toon:"title,omitempty"`
+	Description string `json:"description,omitempty" toon:"description,omitempty"`
+	SiteName    string `json:"siteName,omitempty" toon:"siteName,omitempty"`
+	Language    string `json:"language,omitempty" toon:"language,omitempty"`
+}
+
+// Extras holds additional data extracted from a page
+type Extras struct {
+	ImageLinks []string `json:"imageLinks,omitempty" toon:"imageLinks,omitempty"`
+	Links      []string `json:"links,omitempty" toon:"links,omitempty"`
+}
+
+// CostDollars reports the cost of a request
+type CostDollars struct {
+	Total float64 `json:"total" toon:"total"`
 }
 
 // SearchResponse represents the response from search and find-similar APIs
 type SearchResponse struct {
+	RequestID          string         `json:"requestId,omitempty" toon:"requestId,omitempty"`
 	Results            []SearchResult `json:"results" toon:"results"`
 	AutopromptString   string         `json:"autopromptString,omitempty" toon:"autopromptString,omitempty"`
 	ResolvedSearchType string         `json:"resolvedSearchType,omitempty" toon:"resolvedSearchType,omitempty"`
+	CostDollars        *CostDollars   `json:"costDollars,omitempty" toon:"costDollars,omitempty"`
+}
+
+// AnswerRequest represents an answer API request. Only non-streaming answers
+// are supported, so Stream is always sent as false.
+type AnswerRequest struct {
+	Query  string `json:"query"`
+	Text   bool   `json:"text,omitempty"`
+	Stream bool   `json:"stream"`
+}
+
+// AnswerResponse represents the response from the answer API: a synthesized
+// answer and the pages it was drawn from
+type AnswerResponse struct {
+	RequestID   string         `json:"requestId,omitempty" toon:"requestId,omitempty"`
+	Answer      string         `json:"answer" toon:"answer"`
+	Citations   []SearchResult `json:"citations" toon:"citations"`
+	CostDollars *CostDollars   `json:"costDollars,omitempty" toon:"costDollars,omitempty"`
+}
+
+// ContentError describes why a content fetch failed
+type ContentError struct {
+	Tag            string `json:"tag,omitempty"`
+	HTTPStatusCode int    `json:"httpStatusCode,omitempty"`
 }
 
 // ContentStatus represents the status of a content fetch
 type ContentStatus struct {
-	ID     string `json:"id" toon:"id"`
-	Status string `json:"status" toon:"status"`
-	Error  *struct {
-		Tag            string `json:"tag,omitempty"`
-		HTTPStatusCode int    `json:"httpStatusCode,omitempty"`
-	} `json:"error,omitempty"`
+	ID     string        `json:"id" toon:"id"`
+	Status string        `json:"status" toon:"status"`
+	Error  *ContentError `json:"error,omitempty"`
 }
 
 // ContentsResponse represents the response from the contents API
 type ContentsResponse struct {
-	Results  []SearchResult  `json:"results" toon:"results"`
-	Statuses []ContentStatus `json:"statuses,omitempty" toon:"statuses,omitempty"`
+	RequestID   string          `json:"requestId,omitempty" toon:"requestId,omitempty"`
+	Results     []SearchResult  `json:"results" toon:"results"`
+	Context     string          `json:"context,omitempty" toon:"context,omitempty"`
+	Statuses    []ContentStatus `json:"statuses,omitempty" toon:"statuses,omitempty"`
+	CostDollars *CostDollars    `json:"costDollars,omitempty" toon:"costDollars,omitempty"`
 }
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"cmp"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"gopkg.in/yaml.v3"
 )
@@ -11,14 +13,93 @@ import (
 const (
 	configDir  = "exa"
 	configFile = "config.yaml"
+
+	// DefaultWarnThreshold is the default cost confirmation threshold, measured
+	// as number of pages multiplied by number of content options requested.
+	DefaultWarnThreshold = 100
 )
 
+// template is written by Create for a new config file
+const template = `# exa CLI configuration
+# See https://github.com/12458/exa-cli#configuration
+
+# api_key: your-api-key
+
+# Named API keys, selected with --profile or EXA_PROFILE
+# profiles:
+#   client-a:
+#     api_key: key-for-client-a
+# default_profile: client-a
+
+# API endpoint, e.g. an internal gateway or a mock server
+# base_url: https://api.exa.ai
+
+# Confirm requests above this cost (pages x content options)
+# warn_threshold: 100
+
+# Content options enabled by search --full
+# full_contents: [text, summary, highlights]
+`
+
+// pathOverride replaces the default config file location when set
+var pathOverride string
+
+// SetPath makes Path return path instead of the default location, as for the
+// --config flag or EXA_CONFIG environment variable.
+func SetPath(path string) {
+	pathOverride = path
+}
+
+// profileOverride selects the profile GetAPIKey reads when set
+var profileOverride string
+
+// SetProfile makes GetAPIKey return the key of the named profile instead of
+// the default one, as for the --profile flag or EXA_PROFILE environment
+// variable.
+func SetProfile(name string) {
+	profileOverride = name
+}
+
+// DefaultFullContents are the content options enabled by search --full
+var DefaultFullContents = []string{"text", "summary", "highlights"}
+
 type Config struct {
+	APIKey         string             `yaml:"api_key,omitempty"`
+	Profiles       map[string]Profile `yaml:"profiles,omitempty"`
+	DefaultProfile string             `yaml:"default_profile,omitempty"`
+	BaseURL        string             `yaml:"base_url,omitempty"`
+	WarnThreshold  int                `yaml:"warn_threshold,omitempty"`
+	FullContents   []string           `yaml:"full_contents,omitempty"`
+}
+
+// Profile is a named set of credentials in the config file
+type Profile struct {
 	APIKey string `yaml:"api_key"`
 }
 
-// Path returns the path to the config file (~/.config/exa/config.yaml)
+// ProfileNames returns the names of the configured profiles, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Profile returns the name of the profile GetAPIKey reads: the one given to
+// SetProfile, or default_profile. It is empty when neither is set and the
+// top-level api_key is used.
+func (c *Config) Profile() string {
+	return cmp.Or(profileOverride, c.DefaultProfile)
+}
+
+// Path returns the path to the config file: the one given to SetPath, or
+// ~/.config/exa/config.yaml (honouring XDG_CONFIG_HOME)
 func Path() (string, error) {
+	if pathOverride != "" {
+		return pathOverride, nil
+	}
 	configHome := os.Getenv("XDG_CONFIG_HOME")
 	if configHome == "" {
 		home, err := os.UserHomeDir()
@@ -43,12 +124,12 @@ func Load() (*Config, error) {
 		if os.IsNotExist(err) {
 			return &Config{}, nil
 		}
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
 	}
 
 	var cfg Config
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
 	}
 
 	return &cfg, nil
@@ -80,11 +161,83 @@ func Save(cfg *Config) error {
 	return nil
 }
 
-// GetAPIKey returns the API key from the config file, or empty string if not set.
-func GetAPIKey() string {
+// Create writes a commented template config file if none exists yet. It
+// reports whether the file was created.
+func Create() (bool, error) {
+	path, err := Path()
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to check config file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return false, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(template), 0600); err != nil {
+		return false, fmt.Errorf("failed to write config file: %w", err)
+	}
+	return true, nil
+}
+
+// GetAPIKey returns the API key of the selected profile (see Config.Profile),
+// or the top-level api_key if no profile is selected, or empty string if not
+// set. A config file that exists but can't be read or parsed is an error, as
+// is selecting a profile the file doesn't define.
+func GetAPIKey() (string, error) {
 	cfg, err := Load()
 	if err != nil {
-		return ""
+		return "", err
+	}
+	name := cfg.Profile()
+	if name == "" {
+		return cfg.APIKey, nil
+	}
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		path, _ := Path()
+		return "", fmt.Errorf("profile %q not found in config file %s (see exa config list)", name, path)
+	}
+	return profile.APIKey, nil
+}
+
+// GetBaseURL returns the API base URL from the config file, or empty string if
+// not set. A config file that exists but can't be read or parsed is an error.
+func GetBaseURL() (string, error) {
+	cfg, err := Load()
+	if err != nil {
+		return "", err
+	}
+	return cfg.BaseURL, nil
+}
+
+// GetWarnThreshold returns the cost warning threshold from the config file,
+// or DefaultWarnThreshold if not set. A config file that exists but can't be
+// read or parsed is an error.
+func GetWarnThreshold() (int, error) {
+	cfg, err := Load()
+	if err != nil {
+		return 0, err
+	}
+	if cfg.WarnThreshold <= 0 {
+		return DefaultWarnThreshold, nil
+	}
+	return cfg.WarnThreshold, nil
+}
+
+// GetFullContents returns the content options enabled by search --full from the
+// config file, or DefaultFullContents if not set. A config file that exists but
+// can't be read or parsed is an error.
+func GetFullContents() ([]string, error) {
+	cfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.FullContents) == 0 {
+		return DefaultFullContents, nil
 	}
-	return cfg.APIKey
+	return cfg.FullContents, nil
 }
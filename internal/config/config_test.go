@@ -0,0 +1,36 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMalformedConfigIsAnError(t *testing.T) {
+	SetPath(filepath.Join("testdata", "malformed.yaml"))
+	t.Cleanup(func() { SetPath("") })
+
+	getters := map[string]func() error{
+		"GetAPIKey":        func() error { _, err := GetAPIKey(); return err },
+		"GetBaseURL":       func() error { _, err := GetBaseURL(); return err },
+		"GetWarnThreshold": func() error { _, err := GetWarnThreshold(); return err },
+		"GetFullContents":  func() error { _, err := GetFullContents(); return err },
+	}
+	for name, get := range getters {
+		if err := get(); err == nil || !strings.Contains(err.Error(), "failed to parse config file") {
+			t.Errorf("%s: got %v, want a parse error", name, err)
+		}
+	}
+}
+
+func TestMissingConfigUsesDefaults(t *testing.T) {
+	SetPath(filepath.Join(t.TempDir(), "config.yaml"))
+	t.Cleanup(func() { SetPath("") })
+
+	if threshold, err := GetWarnThreshold(); err != nil || threshold != DefaultWarnThreshold {
+		t.Errorf("GetWarnThreshold() = %d, %v, want %d", threshold, err, DefaultWarnThreshold)
+	}
+	if opts, err := GetFullContents(); err != nil || len(opts) != len(DefaultFullContents) {
+		t.Errorf("GetFullContents() = %v, %v, want %v", opts, err, DefaultFullContents)
+	}
+}
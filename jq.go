@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/itchyny/gojq"
+	"github.com/urfave/cli/v3"
+)
+
+// jqQuery compiles the --jq expression, or returns nil if it is unset
+func jqQuery(cmd *cli.Command) (*gojq.Code, error) {
+	expr := cmd.Root().String("jq")
+	if expr == "" {
+		return nil, nil
+	}
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --jq expression: %w", err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --jq expression: %w", err)
+	}
+	return code, nil
+}
+
+// printJQ runs code against the JSON form of v and writes each value it
+// produces: strings as raw lines, anything else as indented JSON.
+func printJQ(w io.Writer, code *gojq.Code, v any) error {
+	// gojq works on plain decoded JSON values, not Go structs
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var input any
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+
+	iter := code.Run(input)
+	for {
+		out, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		if err, ok := out.(error); ok {
+			return fmt.Errorf("jq: %w", err)
+		}
+		if s, ok := out.(string); ok {
+			fmt.Fprintln(w, s)
+			continue
+		}
+		if err := printJSON(w, out); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// displayPrinter formats the numbers in table and report output for
+// --locale. It is nil for the default C locale (period decimals, no digit
+// grouping), which keeps output stable for scripts.
+var displayPrinter *message.Printer
+
+// setLocale sets displayPrinter from --locale: a language tag such as "de" or
+// "fr-CA", a POSIX locale name such as "de_DE.UTF-8", or "auto" to use
+// LC_ALL, LC_NUMERIC or LANG
+func setLocale(value string) error {
+	name := value
+	if value == "auto" {
+		name = cmp.Or(os.Getenv("LC_ALL"), os.Getenv("LC_NUMERIC"), os.Getenv("LANG"))
+	}
+
+	// Strip the codeset and modifier of POSIX names (de_DE.UTF-8@euro)
+	name, _, _ = strings.Cut(name, "@")
+	name, _, _ = strings.Cut(name, ".")
+	if name == "" || name == "C" || name == "POSIX" {
+		displayPrinter = nil
+		return nil
+	}
+
+	tag, err := language.Parse(strings.ReplaceAll(name, "_", "-"))
+	if err != nil {
+		return fmt.Errorf("invalid --locale %q: %w", value, err)
+	}
+	displayPrinter = message.NewPrinter(tag)
+	return nil
+}
+
+// displayScore is formatScore for table and report output, using the
+// --locale decimal separator
+func displayScore(cmd *cli.Command, score float64) string {
+	if displayPrinter == nil {
+		return formatScore(cmd, score)
+	}
+	precision := max(int(cmd.Int("score-precision")), 0)
+	if cmd.Bool("score-as-percent") {
+		return displayPrinter.Sprintf("%.*f%%", precision, score*100)
+	}
+	return displayPrinter.Sprintf("%.*f", precision, score)
+}
+
+// displayInt formats a count for table and report output, with --locale
+// digit grouping
+func displayInt(n int) string {
+	if displayPrinter == nil {
+		return fmt.Sprintf("%d", n)
+	}
+	return displayPrinter.Sprintf("%d", n)
+}
+
+// displayCost formats a dollar cost for table and report output
+func displayCost(dollars float64) string {
+	if displayPrinter == nil {
+		return fmt.Sprintf("$%.4f", dollars)
+	}
+	return displayPrinter.Sprintf("$%.4f", dollars)
+}
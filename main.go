@@ -1,13 +1,34 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"math"
+	"net/url"
 	"os"
+	"os/exec"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/12458/exa-cli/internal/cache"
 	"github.com/12458/exa-cli/internal/client"
 	"github.com/12458/exa-cli/internal/config"
 
@@ -25,60 +46,528 @@ var (
 	date    = "unknown"
 )
 
+// defaultEditor is used by config edit when neither $VISUAL nor $EDITOR is set
+const defaultEditor = "vi"
+
+// startTime is when the CLI started, used to report elapsed time
+var startTime = time.Now()
+
+// elapsed returns the time since startTime. Tests replace it to make output
+// reproducible.
+var elapsed = func() time.Duration {
+	return time.Since(startTime)
+}
+
 func main() {
-	cmd := &cli.Command{
+	if err := newApp().Run(context.Background(), os.Args); err != nil {
+		exit(err)
+	}
+}
+
+// newApp builds the root command with its global flags and subcommands
+func newApp() *cli.Command {
+	return &cli.Command{
 		Name:                  "exa",
 		Usage:                 "CLI tool for the Exa API",
+		Description:           exitHelp,
 		Version:               version,
 		DefaultCommand:        "search",
 		EnableShellCompletion: true,
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			if path := cmd.String("config"); path != "" {
+				config.SetPath(path)
+			}
+			outputFile = cmd.String("output-file")
+			if cmd.Bool("append") {
+				if outputFile == "" {
+					return ctx, fmt.Errorf("--append needs --output-file")
+				}
+				if format := cmd.String("output"); slices.Contains(singleDocumentFormats, format) && !cmd.Bool("quiet") {
+					return ctx, fmt.Errorf("--append can't be used with --output %s, which writes a single document (use jsonl or csv)", format)
+				}
+			}
+			colorMode = cmd.String("color")
+			if !slices.Contains(colorModes, colorMode) {
+				return ctx, fmt.Errorf("invalid --color %q (valid: %s)", colorMode, strings.Join(colorModes, ", "))
+			}
+			if cmd.Bool("no-color") {
+				if colorMode == "always" {
+					return ctx, fmt.Errorf("--no-color and --color always can't be combined")
+				}
+				colorMode = "never"
+			}
+			if colorMode == "always" {
+				// The color package checks NO_COLOR itself for every new color
+				_ = os.Unsetenv("NO_COLOR")
+			}
+			color.NoColor = !colorEnabled()
+			config.SetProfile(cmd.String("profile"))
+			if dir := cmd.String("cache-dir"); dir != "" {
+				cache.SetDir(dir)
+			}
+			if err := setLocale(cmd.String("locale")); err != nil {
+				return ctx, err
+			}
+			if cmd.Duration("timeout") < 0 {
+				return ctx, fmt.Errorf("timeout must not be negative")
+			}
+			if cmd.Bool("fail-fast") && cmd.Bool("best-effort") {
+				return ctx, fmt.Errorf("--fail-fast and --best-effort are mutually exclusive")
+			}
+			return ctx, nil
+		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "api-key",
 				Usage:   "Exa API key (overrides EXA_API_KEY environment variable)",
 				Sources: cli.EnvVars("EXA_API_KEY"),
 			},
+			&cli.StringFlag{
+				Name:    "api-key-file",
+				Usage:   "Read the Exa API key from a file",
+				Sources: cli.EnvVars("EXA_API_KEY_FILE"),
+			},
+			&cli.StringFlag{
+				Name:    "base-url",
+				Usage:   "Exa API base URL, e.g. for a gateway or mock server (overrides base_url in the config file)",
+				Sources: cli.EnvVars("EXA_BASE_URL"),
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Usage:   "Path to the config file (default ~/.config/exa/config.yaml)",
+				Sources: cli.EnvVars("EXA_CONFIG"),
+			},
+			&cli.StringFlag{
+				Name:    "profile",
+				Usage:   "Use the API key of this config file profile (default: default_profile)",
+				Sources: cli.EnvVars("EXA_PROFILE"),
+			},
+			&cli.StringFlag{
+				Name:    "cache-dir",
+				Usage:   "Cache directory (default $XDG_CACHE_HOME/exa or ~/.cache/exa)",
+				Sources: cli.EnvVars("EXA_CACHE_DIR"),
+			},
 			&cli.StringFlag{
 				Name:    "output",
 				Aliases: []string{"o"},
-				Usage:   "Output format: table, json, toon",
+				Usage:   "Output format: table, json, json-stable, jsonl, csv, toon, report, mermaid, markdown",
 				Value:   "table",
 			},
+			&cli.StringFlag{
+				Name:  "color",
+				Usage: "Color output: auto (on a terminal unless NO_COLOR is set), always, never",
+				Value: "auto",
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Disable color output, same as --color never",
+			},
+			&cli.BoolFlag{
+				Name:  "no-pager",
+				Usage: "Don't page long table/markdown output through $PAGER",
+			},
+			&cli.BoolFlag{
+				Name:  "no-meta",
+				Usage: "Omit the meta object (cost, request ID, timing) from JSON output",
+			},
+			&cli.StringFlag{
+				Name:  "template-file",
+				Usage: "Render output with a Go text/template file; other *.tmpl files in its directory can be included as partials",
+			},
+			&cli.StringFlag{
+				Name:  "jq",
+				Usage: "Filter the JSON output with a jq expression, printing string results raw",
+			},
+			&cli.StringSliceFlag{
+				Name:  "project",
+				Usage: "Keep only these result fields in JSON/TOON output, e.g. url,title,text",
+			},
+			&cli.StringFlag{
+				Name:  "json-root",
+				Usage: "Wrap json and json-stable output in an object under this key",
+			},
+			&cli.BoolFlag{
+				Name:  "echo-request",
+				Usage: "Include the request body that was sent under a request key in JSON output",
+			},
+			&cli.StringFlag{
+				Name:    "output-file",
+				Aliases: []string{"O"},
+				Usage:   "Write the output to this file instead of stdout (without colors unless --color always, replacing the file)",
+			},
+			&cli.BoolFlag{
+				Name:  "append",
+				Usage: "Append to the --output-file instead of replacing it, without repeating the CSV header",
+			},
+			&cli.StringFlag{
+				Name:  "also-json",
+				Usage: "Also write the results as JSON to this file, alongside the --output format on stdout",
+			},
+			&cli.StringFlag{
+				Name:  "also-csv",
+				Usage: "Also write the results as CSV to this file, alongside the --output format on stdout",
+			},
+			&cli.BoolFlag{
+				Name:  "estimate-tokens",
+				Usage: fmt.Sprintf("Print an estimate of the output's LLM token count to stderr (~%d chars/token)", charsPerToken),
+			},
+			&cli.BoolFlag{
+				Name:  "toon-fallback",
+				Usage: "Write JSON instead, with a warning, when a response can't be encoded as TOON",
+			},
+			&cli.BoolFlag{
+				Name:  "toon-header",
+				Usage: "Prepend a comment line describing the result record shape to TOON output",
+			},
+			&cli.StringFlag{
+				Name:  "locale",
+				Usage: "Format numbers in table and report output for a locale, e.g. de or fr_FR.UTF-8, or auto to use $LANG (default: C)",
+			},
+			&cli.BoolFlag{
+				Name:  "csv-bom",
+				Usage: "Start CSV output with a UTF-8 byte order mark so Excel detects the encoding",
+			},
 			&cli.BoolFlag{
 				Name:    "quiet",
 				Aliases: []string{"q"},
 				Usage:   "Quiet mode: output only URLs (search) or text (contents) for scripting",
 			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "Skip cost warnings and confirmation prompts",
+			},
+			&cli.BoolFlag{
+				Name:  "fail-fast",
+				Usage: "In commands that make several API calls (batched contents, research), stop at the first failed call",
+			},
+			&cli.BoolFlag{
+				Name:  "best-effort",
+				Usage: "In commands that make several API calls, carry on past failed calls and exit non-zero with a summary (default)",
+			},
+			&cli.BoolFlag{
+				Name:  "verbose",
+				Usage: "Log diagnostic information (request timing, request IDs) to stderr",
+			},
+			&cli.BoolFlag{
+				Name:  "retries-verbose",
+				Usage: "Log each retry (attempt, status and wait) to stderr, without the rest of --verbose",
+			},
+			&cli.StringFlag{
+				Name:  "log-format",
+				Usage: "Log format for verbose output: text, json",
+				Value: "text",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Give up on the command's API requests after this long, e.g. 30s, including retries (0 = no limit)",
+			},
+			&cli.DurationFlag{
+				Name:  "attempt-timeout",
+				Usage: "Timeout for each individual HTTP attempt, e.g. 20s (0 = no limit)",
+			},
+			&cli.IntFlag{
+				Name:  "max-retries",
+				Usage: "Retry requests that fail with 429 or a 5xx status up to this many times (0 = no retries)",
+				Value: 3,
+			},
+			&cli.DurationFlag{
+				Name:  "retry-backoff",
+				Usage: "Base wait before a retry, doubled for each later one except with --backoff constant (a 429's Retry-After takes precedence)",
+				Value: 500 * time.Millisecond,
+			},
+			&cli.StringFlag{
+				Name:  "backoff",
+				Usage: "Retry wait strategy: full-jitter, equal-jitter, exponential, constant",
+				Value: string(client.BackoffFullJitter),
+			},
+			&cli.IntFlag{
+				Name:  "concurrency-limit",
+				Usage: "Maximum number of API requests in flight at once",
+				Value: 8,
+			},
+			&cli.BoolFlag{
+				Name:  "idempotency",
+				Usage: "Send an Idempotency-Key header, constant across retries of the same request",
+			},
+			&cli.StringFlag{
+				Name:    "signing-secret",
+				Usage:   "Sign request bodies with HMAC-SHA256 using this secret (for signing gateways)",
+				Sources: cli.EnvVars("EXA_SIGNING_SECRET"),
+			},
+			&cli.StringFlag{
+				Name:  "signature-header",
+				Usage: "Header carrying the request signature when --signing-secret is set",
+				Value: "X-Signature",
+			},
+			&cli.StringFlag{
+				Name:  "ca-cert",
+				Usage: "PEM file with an extra root CA to trust, e.g. for a corporate proxy",
+			},
+			&cli.BoolFlag{
+				Name:  "insecure-skip-verify",
+				Usage: "Disable TLS certificate verification (dangerous: exposes your API key to interception)",
+			},
 		},
 		Commands: []*cli.Command{
 			searchCmd(),
 			contentsCmd(),
+			findSimilarCmd(),
+			answerCmd(),
+			researchCmd(),
 			configureCmd(),
+			configCmd(),
+			cacheCmd(),
 			completionCmd(),
 			versionCmd(),
 		},
 	}
-
-	if err := cmd.Run(context.Background(), os.Args); err != nil {
-		log.Fatal(err)
-	}
 }
 
-// isTerminal returns true if stdout is a terminal (not piped)
+// outputFile is the --output-file path, empty when output goes to stdout
+var outputFile string
+
+// singleDocumentFormats are the --output formats that write one document,
+// which can't be appended to another
+var singleDocumentFormats = []string{"json", "json-stable", "toon", "mermaid"}
+
+// isTerminal returns true if output goes to a terminal: stdout is a terminal
+// (not piped) and --output-file isn't set
 func isTerminal() bool {
-	return term.IsTerminal(int(os.Stdout.Fd()))
+	return outputFile == "" && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// colorModes are the values accepted by --color
+var colorModes = []string{"auto", "always", "never"}
+
+// colorMode is the --color setting, "never" with --no-color
+var colorMode = "auto"
+
+// colorEnabled reports whether output is colored: --color always or never
+// (or --no-color) if given, otherwise not when NO_COLOR is set, otherwise
+// when output goes to a terminal
+func colorEnabled() bool {
+	switch colorMode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal()
 }
 
-// getAPIKey returns the API key from flag, env var, or config file (in that priority order)
-func getAPIKey(cmd *cli.Command) string {
+// getAPIKey returns the API key from flag, env var, key file, or config file (in that priority order)
+func getAPIKey(cmd *cli.Command) (string, error) {
 	// Check flag/env first (handled by cli library)
 	if key := cmd.Root().String("api-key"); key != "" {
-		return key
+		return key, nil
+	}
+	// Then a key file, as mounted by secrets managers and Docker
+	if path := cmd.Root().String("api-key-file"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key file: %w", err)
+		}
+		key := strings.TrimSpace(string(data))
+		if key == "" {
+			return "", fmt.Errorf("API key file %s is empty", path)
+		}
+		return key, nil
 	}
 	// Fall back to config file
 	return config.GetAPIKey()
 }
 
+// getBaseURL returns the API base URL from flag, env var, or config file (in
+// that priority order), or empty string for the default. The URL must be
+// http or https.
+func getBaseURL(cmd *cli.Command) (string, error) {
+	// Flag/env are handled by the cli library
+	baseURL := cmd.Root().String("base-url")
+	source := "--base-url/EXA_BASE_URL"
+	if baseURL == "" {
+		var err error
+		if baseURL, err = config.GetBaseURL(); err != nil {
+			return "", err
+		}
+		source = "base_url in the config file"
+	}
+	if baseURL == "" {
+		return "", nil
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: %w", source, baseURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid %s %q: must be an http:// or https:// URL", source, baseURL)
+	}
+	return baseURL, nil
+}
+
+// newLogger builds the diagnostic logger from the --verbose and --log-format flags.
+// Logs are discarded unless --verbose is set.
+func newLogger(cmd *cli.Command) (*slog.Logger, error) {
+	if !cmd.Root().Bool("verbose") {
+		return slog.New(slog.DiscardHandler), nil
+	}
+
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	switch format := cmd.Root().String("log-format"); format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log-format %q: must be text or json", format)
+	}
+}
+
+// newClient creates an API client configured from the global flags.
+func newClient(cmd *cli.Command) (*client.Client, error) {
+	apiKey, err := getAPIKey(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return newClientWithKey(cmd, apiKey)
+}
+
+// newClientWithKey creates an API client for apiKey configured from the global flags.
+func newClientWithKey(cmd *cli.Command, apiKey string) (*client.Client, error) {
+	logger, err := newLogger(cmd)
+	if err != nil {
+		return nil, err
+	}
+	opts := []client.ClientOption{
+		client.WithLogger(logger),
+		client.WithIdempotency(cmd.Root().Bool("idempotency")),
+	}
+
+	baseURL, err := getBaseURL(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if baseURL != "" {
+		opts = append(opts, client.WithBaseURL(baseURL))
+	}
+
+	if secret := cmd.Root().String("signing-secret"); secret != "" {
+		opts = append(opts, client.WithRequestHook(client.HMACSigner(secret, cmd.Root().String("signature-header"))))
+	}
+
+	limit := int(cmd.Root().Int("concurrency-limit"))
+	if limit < 1 {
+		return nil, fmt.Errorf("concurrency-limit must be at least 1")
+	}
+	opts = append(opts, client.WithConcurrencyLimit(limit))
+
+	tlsConfig, err := newTLSConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts = append(opts, client.WithTLSConfig(tlsConfig))
+	}
+
+	if d := cmd.Root().Duration("attempt-timeout"); d > 0 {
+		opts = append(opts, client.WithAttemptTimeout(d))
+	} else if d < 0 {
+		return nil, fmt.Errorf("attempt-timeout must not be negative")
+	}
+
+	retries := int(cmd.Root().Int("max-retries"))
+	if retries < 0 {
+		return nil, fmt.Errorf("max-retries must not be negative")
+	}
+	backoff := cmd.Root().Duration("retry-backoff")
+	if backoff <= 0 {
+		return nil, fmt.Errorf("retry-backoff must be positive")
+	}
+	opts = append(opts, client.WithRetries(retries, backoff))
+	strategy := client.Backoff(cmd.Root().String("backoff"))
+	if !slices.Contains(client.Backoffs, strategy) {
+		return nil, fmt.Errorf("invalid backoff %q: must be full-jitter, equal-jitter, exponential or constant", strategy)
+	}
+	opts = append(opts, client.WithBackoff(strategy))
+	if cmd.Root().Bool("retries-verbose") {
+		opts = append(opts, client.WithRetryLog(os.Stderr))
+	}
+
+	return client.New(apiKey, opts...)
+}
+
+// withTimeout bounds ctx by the global --timeout, if set. Call it after any
+// prompts so the user's thinking time isn't counted.
+func withTimeout(ctx context.Context, cmd *cli.Command) (context.Context, context.CancelFunc) {
+	d := cmd.Root().Duration("timeout")
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, d, fmt.Errorf("request timed out after %s (see --timeout)", d))
+}
+
+// timeoutErr returns the --timeout error in place of err if ctx's deadline
+// has passed, rather than a raw "context deadline exceeded"
+func timeoutErr(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &exitCodeError{exitNetwork, context.Cause(ctx)}
+	}
+	return err
+}
+
+// lowQuotaThreshold is the remaining request count below which
+// warnLowQuota prints a warning
+const lowQuotaThreshold = 5
+
+// warnLowQuota prints a warning to stderr when the last rate limit headers
+// seen by c report fewer than lowQuotaThreshold requests remaining
+func warnLowQuota(c *client.Client) {
+	rl, ok := c.RateLimit()
+	if !ok || rl.Remaining >= lowQuotaThreshold {
+		return
+	}
+	msg := fmt.Sprintf("warning: rate limit nearly exhausted, %d requests remaining", rl.Remaining)
+	if rl.Reset > 0 {
+		msg += fmt.Sprintf(", resets in %s", rl.Reset)
+	}
+	fmt.Fprintln(os.Stderr, msg)
+}
+
+// newTLSConfig builds TLS settings from --ca-cert and --insecure-skip-verify.
+// It returns nil when neither is set, leaving the system defaults in place.
+func newTLSConfig(cmd *cli.Command) (*tls.Config, error) {
+	caCert := cmd.Root().String("ca-cert")
+	insecure := cmd.Root().Bool("insecure-skip-verify")
+	if caCert == "" && !insecure {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{}
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", caCert)
+		}
+		cfg.RootCAs = pool
+	}
+	if insecure {
+		fmt.Fprintln(os.Stderr, "warning: --insecure-skip-verify disables TLS certificate verification; your API key can be intercepted")
+		cfg.InsecureSkipVerify = true
+	}
+	return cfg, nil
+}
+
 func searchCmd() *cli.Command {
 	return &cli.Command{
 		Name:      "search",
@@ -90,163 +579,388 @@ func searchCmd() *cli.Command {
   exa search -n 5 --summary "golang best practices"
   exa search -i github.com -i stackoverflow.com "error handling"
   exa search -c news --max-age-hours 24 "tech layoffs"`,
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:    "type",
-				Aliases: []string{"t"},
-				Usage:   "Search type: auto, fast",
-				Value:   "auto",
-			},
-			&cli.IntFlag{
-				Name:    "num-results",
-				Aliases: []string{"n"},
-				Usage:   "Number of results (1-100)",
-				Value:   10,
-			},
-			&cli.BoolFlag{
-				Name:  "text",
-				Usage: "Include full text content",
-			},
-			&cli.IntFlag{
-				Name:  "text-max-chars",
-				Usage: "Maximum characters for text content",
-			},
-			&cli.BoolFlag{
-				Name:  "text-include-html",
-				Usage: "Include HTML tags in text content",
-			},
-			&cli.StringFlag{
-				Name:  "text-verbosity",
-				Usage: "Text verbosity: compact, standard, full",
-			},
-			&cli.BoolFlag{
-				Name:    "highlights",
-				Aliases: []string{"H"},
-				Usage:   "Include highlights",
-			},
-			&cli.BoolFlag{
-				Name:    "summary",
-				Aliases: []string{"s"},
-				Usage:   "Include AI-generated summary",
-			},
-			&cli.StringFlag{
-				Name:  "summary-query",
-				Usage: "Custom query for summary generation",
-			},
-			&cli.StringFlag{
-				Name:  "summary-schema",
-				Usage: "JSON schema for structured summary extraction",
-			},
-			&cli.StringSliceFlag{
-				Name:    "include-domains",
-				Aliases: []string{"i"},
-				Usage:   "Only include results from these domains",
-			},
-			&cli.StringSliceFlag{
-				Name:    "exclude-domains",
-				Aliases: []string{"x"},
-				Usage:   "Exclude results from these domains",
-			},
-			&cli.StringFlag{
-				Name:  "start-published-date",
-				Usage: "Filter by publish date (ISO 8601)",
+		Flags: slices.Concat(
+			[]cli.Flag{
+				&cli.StringFlag{
+					Name:    "type",
+					Aliases: []string{"t"},
+					Usage:   "Search type: auto, fast",
+					Value:   "auto",
+				},
+				&cli.IntFlag{
+					Name:    "num-results",
+					Aliases: []string{"n"},
+					Usage:   "Number of results; above 100, the search is paginated by excluding the domains already seen",
+					Value:   10,
+				},
 			},
-			&cli.StringFlag{
-				Name:  "end-published-date",
-				Usage: "Filter by publish date (ISO 8601)",
+			contentFlags(),
+			[]cli.Flag{
+				&cli.IntFlag{
+					Name:  "max-chars-total",
+					Usage: "Trim result text client-side to this many characters across all results, keeping earlier results whole",
+				},
+				&cli.BoolFlag{
+					Name:  "columns-from-schema",
+					Usage: "With --output csv, write one column per top-level --summary-schema property",
+				},
+				&cli.BoolFlag{
+					Name:  "full",
+					Usage: "Include text, summary and highlights in the same search call (configurable via full_contents)",
+				},
 			},
-			&cli.StringFlag{
-				Name:    "category",
-				Aliases: []string{"c"},
-				Usage:   "Content category: company, people, tweet, news, research paper, personal site, financial report",
+			domainFlags(),
+			[]cli.Flag{
+				&rawStringSliceFlag{
+					Name:  "exclude-source-domains-of",
+					Usage: "Exclude the domain of this URL, e.g. to find coverage of a story other than the original (repeatable)",
+				},
+				&cli.StringSliceFlag{
+					Name:  "include-domain-glob",
+					Usage: "Only keep results whose host matches these glob patterns, e.g. '*.gov' (filtered client-side)",
+				},
+				&cli.StringSliceFlag{
+					Name:  "exclude-domain-glob",
+					Usage: "Drop results whose host matches these glob patterns, e.g. 'blog.*' (filtered client-side)",
+				},
 			},
-			&cli.IntFlag{
-				Name:  "max-age-hours",
-				Usage: "Maximum age of content in hours (0=always livecrawl, -1=cache only)",
+			publishedDateFlags(),
+			[]cli.Flag{
+				&cli.StringFlag{
+					Name:    "category",
+					Aliases: []string{"c"},
+					Usage:   "Content category: company, people, tweet, news, research paper, pdf, personal site, financial report",
+				},
+				&cli.IntFlag{
+					Name:  "max-age-hours",
+					Usage: "Maximum age of content in hours (0=always livecrawl, -1=cache only)",
+				},
+				&cli.BoolFlag{
+					Name:  "stdin",
+					Usage: "Read queries from stdin, one per line, and search for each",
+				},
+				&cli.BoolFlag{
+					Name:  "fail-on-empty",
+					Usage: "Exit with status 6 if the search finds no results (with --stdin, if any query finds none)",
+				},
+				&cli.BoolFlag{
+					Name:  "continue-on-error",
+					Usage: "With --stdin, carry on past queries that fail and exit non-zero at the end",
+				},
+				&cli.StringFlag{
+					Name:  "checkpoint",
+					Usage: "For searches above 100 results, save progress to this file after each page and resume from it when run again",
+				},
+				&cli.IntFlag{
+					Name:  "results-per-query",
+					Usage: "With --stdin, number of results for each query (in place of --num-results)",
+				},
+				&cli.IntFlag{
+					Name:  "total-limit",
+					Usage: "With --stdin, keep at most this many results across all queries, after dropping results repeated across queries",
+				},
+				&cli.StringFlag{
+					Name:  "compare",
+					Usage: "Print added/removed/changed results against a saved JSON output file instead of the results",
+				},
+				&cli.StringFlag{
+					Name:  "merge",
+					Usage: "Merge results into those in a saved JSON output file (- for stdin), de-duplicated by URL",
+				},
+				&cli.StringFlag{
+					Name:  "min-published",
+					Usage: "Drop returned results published before this date (client-side, unlike --start-published-date)",
+				},
+				&cli.StringFlag{
+					Name:  "max-published",
+					Usage: "Drop returned results published after this date or period, e.g. 2024-06 (client-side)",
+				},
+				&cli.BoolFlag{
+					Name:  "keep-undated",
+					Usage: "Keep results without a published date when filtering with --min-published/--max-published",
+				},
+				&cli.StringFlag{
+					Name:  "sort",
+					Usage: "Result order: relevance (API order), date (newest first, undated last)",
+					Value: "relevance",
+				},
+				&cli.BoolFlag{
+					Name:  "pdf-only",
+					Usage: "Only keep results that are PDF documents (client-side)",
+				},
+				&cli.IntFlag{
+					Name:  "max-nodes",
+					Usage: "Maximum number of domain nodes in --output mermaid graphs",
+					Value: 30,
+				},
+				&cli.IntFlag{
+					Name:  "links",
+					Usage: "Include up to N links extracted from each page; --output mermaid draws them as edges between result domains",
+				},
+				&cli.BoolFlag{
+					Name:  "domains-only",
+					Usage: "List the unique result domains ranked by result count (cheap discovery search, no contents)",
+				},
+				&cli.BoolFlag{
+					Name:  "new-only",
+					Usage: "Only show results not seen in previous --new-only runs of the same query",
+				},
+				&cli.BoolFlag{
+					Name:  "show-related",
+					Usage: "Suggest follow-up searches from terms common to result titles (table output)",
+				},
+				&cli.BoolFlag{
+					Name:  "metadata",
+					Usage: "Request page metadata (OpenGraph title, description, site name, language) and show site/language columns",
+				},
+				&cli.BoolFlag{
+					Name:  "first-paragraph",
+					Usage: "In table and markdown output, show only the first paragraph of each result's text",
+				},
+				&cli.BoolFlag{
+					Name:  "highlight-terms",
+					Usage: "Color the query's words in the text and summary columns (terminal only)",
+				},
+				&cli.BoolFlag{
+					Name:  "show-lengths",
+					Usage: "Show character and word count columns for each result's text (use with --text)",
+				},
+				&cli.BoolFlag{
+					Name:  "score-bars",
+					Usage: "Show the score column with a bar scaled across the result set's score range (terminal only)",
+				},
+				&cli.BoolFlag{
+					Name:  "totals",
+					Usage: "Add a totals row to table output: average score and total chars/words (with --show-scores or --show-lengths)",
+				},
 			},
-		},
+			scoreFlags("relevance"),
+			extraFieldFlags(),
+		),
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			if cmd.Args().Len() == 0 {
+			query := cmd.Args().First()
+			if cmd.Bool("stdin") {
+				if err := validateStdinSearch(cmd); err != nil {
+					return err
+				}
+			} else if cmd.IsSet("results-per-query") || cmd.IsSet("total-limit") {
+				return fmt.Errorf("--results-per-query and --total-limit only work with --stdin")
+			} else if args := typedArgs(cmd); len(args) > 1 {
+				return fmt.Errorf("search takes a single query, got %d arguments (quote multi-word queries)", len(args))
+			} else if len(args) == 0 {
 				return fmt.Errorf("query is required")
+			} else if strings.TrimSpace(args[0]) == "" {
+				return fmt.Errorf("query is empty")
+			}
+			if err := resolveFileArgs(cmd); err != nil {
+				return err
+			}
+			if err := validateOutputFlags(cmd); err != nil {
+				return err
+			}
+			if order := cmd.String("sort"); !slices.Contains(sortOrders, order) {
+				return fmt.Errorf("invalid --sort %q (valid: %s)", order, strings.Join(sortOrders, ", "))
+			}
+			if cmd.Int("max-chars-total") < 0 {
+				return fmt.Errorf("max-chars-total must not be negative")
+			}
+			minPublished, maxPublished, err := publishedRange(cmd)
+			if err != nil {
+				return err
 			}
-			query := cmd.Args().First()
 
-			c, err := client.New(getAPIKey(cmd))
+			// Read the saved results first, so a missing or malformed file
+			// doesn't cost a search
+			var saved, baseline []client.SearchResult
+			if path := cmd.String("merge"); path != "" {
+				if saved, err = loadResults(path); err != nil {
+					return err
+				}
+			}
+			if path := cmd.String("compare"); path != "" {
+				if baseline, err = loadResults(path); err != nil {
+					return err
+				}
+			}
+
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			defer warnLowQuota(c)
+
+			if cmd.Bool("stdin") {
+				return searchStdin(ctx, cmd, c, minPublished, maxPublished)
+			}
+
+			req, err := searchRequest(cmd, query)
 			if err != nil {
 				return err
 			}
 
-			req := &client.SearchRequest{
-				Query:      query,
-				Type:       cmd.String("type"),
-				NumResults: int(cmd.Int("num-results")),
-			}
+			if req.Contents != nil && !cmd.Root().Bool("yes") {
+				opts := contentOptions(req.Contents.Text, req.Contents.Summary, req.Contents.Highlights)
+				if err := confirmExpensive(req.NumResults, opts, searchCalls(req.NumResults)); err != nil {
+					return err
+				}
+			}
+
+			if err := echoRequest(cmd, req); err != nil {
+				return err
+			}
+			ctx, cancel := withTimeout(ctx, cmd)
+			defer cancel()
+			result, err := searchPaged(ctx, c, req, cmd.String("checkpoint"))
+			if err != nil {
+				return timeoutErr(ctx, err)
+			}
+
+			if err := filterSearchResults(cmd, query, result, saved, minPublished, maxPublished); err != nil {
+				return err
+			}
+			applyMaxCharsTotal(cmd, result.Results)
+
+			var emptyErr error
+			if cmd.Bool("fail-on-empty") && len(result.Results) == 0 {
+				emptyErr = errNoResults
+			}
+
+			if cmd.Bool("domains-only") {
+				return errors.Join(printOutput(cmd, countDomains(result)), emptyErr)
+			}
+
+			if cmd.String("compare") != "" {
+				return errors.Join(printOutput(cmd, compareResults(baseline, result.Results)), emptyErr)
+			}
+
+			return errors.Join(printOutput(cmd, result), emptyErr)
+		},
+	}
+}
+
+// typedArgs returns the positional arguments of cmd as they were typed. The
+// flag parser stops at the first blank argument and drops it along with
+// everything after it, so `search "" foo` parses as no arguments; the dropped
+// ones are recovered from the root command's unparsed arguments.
+func typedArgs(cmd *cli.Command) []string {
+	args := cmd.Args().Slice()
+	raw := cmd.Root().Args().Slice()
+	// raw[0] is the subcommand's name
+	for i := 1; i < len(raw); i++ {
+		arg := raw[i]
+		switch {
+		case arg == "--":
+			return args
+		case strings.TrimSpace(arg) == "":
+			return append(args, raw[i:]...)
+		case strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") && flagTakesValue(cmd, strings.TrimLeft(arg, "-")):
+			i++
+		}
+	}
+	return args
+}
+
+// flagTakesValue reports whether the flag name, of cmd or one of its parents,
+// is followed by a value rather than being a boolean switch
+func flagTakesValue(cmd *cli.Command, name string) bool {
+	for _, c := range cmd.Lineage() {
+		for _, f := range c.Flags {
+			if slices.Contains(f.Names(), name) {
+				df, ok := f.(cli.DocGenerationFlag)
+				return ok && df.TakesValue()
+			}
+		}
+	}
+	return false
+}
+
+// searchRequest builds the search request for query from the search flags
+func searchRequest(cmd *cli.Command, query string) (*client.SearchRequest, error) {
+	req := &client.SearchRequest{
+		Query:      query,
+		Type:       cmd.String("type"),
+		NumResults: int(cmd.Int("num-results")),
+	}
+
+	var err error
+	if req.Contents, err = searchContentsOptions(cmd); err != nil {
+		return nil, err
+	}
+	if links := int(cmd.Int("links")); links < 0 {
+		return nil, fmt.Errorf("links must not be negative")
+	} else if links > 0 {
+		if req.Contents == nil {
+			req.Contents = &client.ContentsOptions{}
+		}
+		req.Contents.Extras = &client.ExtrasOptions{Links: links}
+	}
+
+	if domains := cmd.StringSlice("include-domains"); len(domains) > 0 {
+		req.IncludeDomains = domains
+	}
+	excluded, err := excludedDomains(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(excluded) > 0 {
+		req.ExcludeDomains = excluded
+	}
+	if date := cmd.String("start-published-date"); date != "" {
+		req.StartPublishedDate = date
+	}
+	if date := cmd.String("end-published-date"); date != "" {
+		req.EndPublishedDate = date
+	}
+	if cat := cmd.String("category"); cat != "" {
+		req.Category = cat
+	}
+	if cmd.IsSet("max-age-hours") {
+		hours := int(cmd.Int("max-age-hours"))
+		req.MaxAgeHours = &hours
+	}
+
+	if cmd.Bool("domains-only") {
+		// Cast a wide net without paying for contents
+		req.Contents = nil
+		if !cmd.IsSet("num-results") {
+			req.NumResults = 100
+		}
+	}
+
+	extra, err := parseExtraFields(cmd)
+	if err != nil {
+		return nil, err
+	}
+	req.ExtraFields = extra
+
+	return req, nil
+}
 
-			// Build contents options
-			hasTextOpts := cmd.Bool("text") || cmd.Int("text-max-chars") > 0 || cmd.Bool("text-include-html") || cmd.String("text-verbosity") != ""
-			hasSummaryOpts := cmd.Bool("summary") || cmd.String("summary-query") != "" || cmd.String("summary-schema") != ""
-			if hasTextOpts || cmd.Bool("highlights") || hasSummaryOpts {
-				req.Contents = &client.ContentsOptions{}
+// filterSearchResults applies the client-side filters and --sort to the
+// results of a search for query, merging them into the saved results of
+// --merge
+func filterSearchResults(cmd *cli.Command, query string, result *client.SearchResponse, saved []client.SearchResult, minPublished, maxPublished time.Time) error {
+	if err := filterDomainGlobs(result, cmd.StringSlice("include-domain-glob"), cmd.StringSlice("exclude-domain-glob")); err != nil {
+		return err
+	}
 
-				if hasTextOpts {
-					if cmd.Int("text-max-chars") > 0 || cmd.Bool("text-include-html") || cmd.String("text-verbosity") != "" {
-						req.Contents.Text = &client.TextOptions{
-							MaxCharacters:   int(cmd.Int("text-max-chars")),
-							IncludeHtmlTags: cmd.Bool("text-include-html"),
-							Verbosity:       cmd.String("text-verbosity"),
-						}
-					} else {
-						req.Contents.Text = true
-					}
-				}
-				if cmd.Bool("highlights") {
-					req.Contents.Highlights = true
-				}
-				if hasSummaryOpts {
-					if cmd.String("summary-query") != "" || cmd.String("summary-schema") != "" {
-						opts := &client.SummaryOptions{Query: cmd.String("summary-query")}
-						if schema := cmd.String("summary-schema"); schema != "" {
-							var schemaObj any
-							if err := json.Unmarshal([]byte(schema), &schemaObj); err != nil {
-								return fmt.Errorf("invalid summary-schema JSON: %w", err)
-							}
-							opts.Schema = schemaObj
-						}
-						req.Contents.Summary = opts
-					} else {
-						req.Contents.Summary = true
-					}
-				}
-			}
+	if cmd.Bool("pdf-only") {
+		filterPDFResults(result)
+	}
 
-			if domains := cmd.StringSlice("include-domains"); len(domains) > 0 {
-				req.IncludeDomains = domains
-			}
-			if domains := cmd.StringSlice("exclude-domains"); len(domains) > 0 {
-				req.ExcludeDomains = domains
-			}
-			if date := cmd.String("start-published-date"); date != "" {
-				req.StartPublishedDate = date
-			}
-			if date := cmd.String("end-published-date"); date != "" {
-				req.EndPublishedDate = date
-			}
-			if cat := cmd.String("category"); cat != "" {
-				req.Category = cat
-			}
-			if cmd.IsSet("max-age-hours") {
-				hours := int(cmd.Int("max-age-hours"))
-				req.MaxAgeHours = &hours
-			}
+	result.Results = filterPublished(result.Results, minPublished, maxPublished, cmd.Bool("keep-undated"))
 
-			result, err := c.Search(ctx, req)
-			if err != nil {
-				return err
-			}
+	if cmd.Bool("new-only") {
+		if err := filterNewResults(query, result); err != nil {
+			return err
+		}
+	}
 
-			return printOutput(cmd, result)
-		},
+	if cmd.String("merge") != "" {
+		result.Results = mergeResults(saved, result.Results)
 	}
+
+	return sortResults(result.Results, cmd.String("sort"))
 }
 
 func contentsCmd() *cli.Command {
@@ -259,82 +973,169 @@ func contentsCmd() *cli.Command {
   exa contents https://example.com
   exa contents --summary https://example.com https://another.com
   exa contents -q https://example.com | head -100`,
-		Flags: []cli.Flag{
-			&cli.BoolFlag{
-				Name:    "text",
-				Aliases: []string{"t"},
-				Usage:   "Include full text content",
-				Value:   true,
-			},
-			&cli.IntFlag{
-				Name:  "text-max-chars",
-				Usage: "Maximum characters for text content",
-			},
-			&cli.BoolFlag{
-				Name:  "text-include-html",
-				Usage: "Include HTML tags in text content",
-			},
-			&cli.StringFlag{
-				Name:  "text-verbosity",
-				Usage: "Text verbosity: compact, standard, full",
-			},
-			&cli.BoolFlag{
-				Name:    "highlights",
-				Aliases: []string{"H"},
-				Usage:   "Include highlights",
-			},
-			&cli.BoolFlag{
-				Name:    "summary",
-				Aliases: []string{"s"},
-				Usage:   "Include AI-generated summary",
-			},
-			&cli.StringFlag{
-				Name:  "summary-query",
-				Usage: "Custom query for summary generation",
-			},
-			&cli.StringFlag{
-				Name:  "summary-schema",
-				Usage: "JSON schema for structured summary extraction",
-			},
-			&cli.IntFlag{
-				Name:    "subpages",
-				Aliases: []string{"p"},
-				Usage:   "Number of subpages to crawl",
-			},
-			&cli.StringSliceFlag{
-				Name:  "subpage-target",
-				Usage: "Keywords to target when crawling subpages",
-			},
-			&cli.IntFlag{
-				Name:  "max-age-hours",
-				Usage: "Maximum age of content in hours",
-			},
-			&cli.IntFlag{
-				Name:  "livecrawl-timeout",
-				Usage: "Timeout in ms for live crawling",
-			},
-			&cli.BoolFlag{
-				Name:    "context",
-				Aliases: []string{"C"},
-				Usage:   "Return all results combined into a single string for RAG",
-			},
-			&cli.IntFlag{
-				Name:  "context-max-chars",
-				Usage: "Maximum characters for context string",
+		Flags: slices.Concat(
+			[]cli.Flag{
+				&cli.BoolFlag{
+					Name:    "text",
+					Aliases: []string{"t"},
+					Usage:   "Include full text content",
+					Value:   true,
+				},
+				&cli.IntFlag{
+					Name:  "text-max-chars",
+					Usage: "Maximum characters for text content",
+				},
+				&cli.IntFlag{
+					Name:  "max-chars-total",
+					Usage: "Trim result text client-side to this many characters across all results, keeping earlier results whole",
+				},
+				&cli.BoolFlag{
+					Name:  "text-include-html",
+					Usage: "Include HTML tags in text content",
+				},
+				&cli.StringFlag{
+					Name:  "text-verbosity",
+					Usage: "Text verbosity: compact, standard, full",
+				},
+				&cli.BoolFlag{
+					Name:    "highlights",
+					Aliases: []string{"H"},
+					Usage:   "Include highlights",
+				},
+				&cli.BoolFlag{
+					Name:    "summary",
+					Aliases: []string{"s"},
+					Usage:   "Include AI-generated summary",
+				},
+				&cli.StringFlag{
+					Name:  "summary-query",
+					Usage: "Custom query for summary generation (@file to read a file, - for stdin)",
+				},
+				&cli.StringFlag{
+					Name:  "summary-schema",
+					Usage: "JSON schema for structured summary extraction (@file to read a file, - for stdin)",
+				},
+				&cli.BoolFlag{
+					Name:  "columns-from-schema",
+					Usage: "With --output csv, write one column per top-level --summary-schema property",
+				},
+				&cli.IntFlag{
+					Name:    "subpages",
+					Aliases: []string{"p"},
+					Usage:   "Number of subpages to crawl",
+				},
+				&cli.StringSliceFlag{
+					Name:  "subpage-target",
+					Usage: "Keywords to target when crawling subpages",
+				},
+				&cli.IntFlag{
+					Name:  "max-age-hours",
+					Usage: "Maximum age of content in hours",
+				},
+				&cli.IntFlag{
+					Name:  "livecrawl-timeout",
+					Usage: "Timeout in ms for live crawling (send livecrawl fields the CLI doesn't model with --set/--set-json)",
+				},
+				&cli.BoolFlag{
+					Name:  "prefer-cache",
+					Usage: fmt.Sprintf("Use cached content when available, livecrawling only on a miss (max age %dh, livecrawl fallback)", preferCacheMaxAgeHours),
+				},
+				&cli.BoolFlag{
+					Name:  "force-live",
+					Usage: "Always livecrawl for fresh content (max age 0, livecrawl always)",
+				},
+				&cli.BoolFlag{
+					Name:    "context",
+					Aliases: []string{"C"},
+					Usage:   "Return all results combined into a single string for RAG",
+				},
+				&cli.IntFlag{
+					Name:  "context-max-chars",
+					Usage: "Maximum characters for context string",
+				},
+				&cli.IntFlag{
+					Name:  "context-max-bytes",
+					Usage: "Trim the context string to at most this many bytes (UTF-8 safe)",
+				},
+				&cli.IntFlag{
+					Name:  "max-tokens",
+					Usage: "Build the context from page text locally, adding whole pages up to this token budget (~4 chars/token)",
+				},
+				&cli.BoolFlag{
+					Name:  "metadata",
+					Usage: "Request page metadata (OpenGraph title, description, site name, language) for the frontmatter",
+				},
+				&cli.BoolFlag{
+					Name:  "screenshot",
+					Usage: fmt.Sprintf("Include the page image and up to %d image URLs per page", screenshotImageLinks),
+				},
+				&cli.IntFlag{
+					Name:  "links",
+					Usage: "Include up to N links extracted from each page (listed under \"Links\" in markdown output)",
+				},
+				&cli.BoolFlag{
+					Name:  "first-paragraph",
+					Usage: "In table and markdown output, show only the first paragraph of each result's text",
+				},
+				&cli.BoolFlag{
+					Name:  "highlight-terms",
+					Usage: "Color the --summary-query words in text and summaries (terminal only)",
+				},
+				&cli.BoolFlag{
+					Name:  "toc",
+					Usage: "Start markdown output with a linked table of contents",
+				},
+				&cli.BoolFlag{
+					Name:  "diff",
+					Usage: "Livecrawl the URLs and print a diff against the previously cached text (caches the new version)",
+				},
+				&cli.BoolFlag{
+					Name:  "text-only-successful",
+					Usage: "Omit results whose status is not success from the output, summarizing failures on stderr",
+				},
+				&cli.BoolFlag{
+					Name:  "inline-status",
+					Usage: "In JSON output, rely on each result's status field and omit the separate statuses array",
+				},
+				&cli.IntFlag{
+					Name:  "batch-size",
+					Usage: fmt.Sprintf("Split URLs into batches of this size, one request per batch (max %d)", client.MaxContentsIDs),
+				},
+				&cli.IntFlag{
+					Name:  "concurrency",
+					Usage: "Fetch this many batches at once; above 1 without --batch-size, each URL is its own request",
+					Value: 1,
+				},
+				&cli.StringFlag{
+					Name:  "split-output",
+					Usage: "Write each result to its own markdown file with frontmatter in this directory, named from its title or host",
+				},
 			},
-		},
+			extraFieldFlags(),
+		),
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			if cmd.Args().Len() == 0 {
 				return fmt.Errorf("at least one URL is required")
 			}
+			if err := resolveFileArgs(cmd); err != nil {
+				return err
+			}
+			if err := validateOutputFlags(cmd); err != nil {
+				return err
+			}
 
-			c, err := client.New(getAPIKey(cmd))
+			c, err := newClient(cmd)
 			if err != nil {
 				return err
 			}
+			defer warnLowQuota(c)
 
+			ids, err := normalizeURLs(cmd.Args().Slice())
+			if err != nil {
+				return err
+			}
 			req := &client.ContentsRequest{
-				IDs: cmd.Args().Slice(),
+				IDs: ids,
 			}
 
 			// Build text options
@@ -357,9 +1158,9 @@ func contentsCmd() *cli.Command {
 				if cmd.String("summary-query") != "" || cmd.String("summary-schema") != "" {
 					opts := &client.SummaryOptions{Query: cmd.String("summary-query")}
 					if schema := cmd.String("summary-schema"); schema != "" {
-						var schemaObj any
-						if err := json.Unmarshal([]byte(schema), &schemaObj); err != nil {
-							return fmt.Errorf("invalid summary-schema JSON: %w", err)
+						schemaObj, err := parseSummarySchema(schema)
+						if err != nil {
+							return err
 						}
 						opts.Schema = schemaObj
 					}
@@ -378,11 +1179,28 @@ func contentsCmd() *cli.Command {
 				hours := int(cmd.Int("max-age-hours"))
 				req.MaxAgeHours = &hours
 			}
+			if err := applyFreshness(cmd, req); err != nil {
+				return err
+			}
 			if cmd.Int("livecrawl-timeout") > 0 {
 				req.LivecrawlTimeout = int(cmd.Int("livecrawl-timeout"))
+				livecrawl := time.Duration(req.LivecrawlTimeout) * time.Millisecond
+				if timeout := cmd.Root().Duration("timeout"); timeout > 0 && livecrawl >= timeout {
+					fmt.Fprintf(os.Stderr, "warning: --livecrawl-timeout %s is not shorter than --timeout %s, so slow crawls will time out the whole request instead of falling back to cached content\n", livecrawl, timeout)
+				}
+			}
+			req.Metadata = cmd.Bool("metadata")
+			if cmd.Int("links") < 0 {
+				return fmt.Errorf("links must not be negative")
+			}
+			if cmd.Bool("screenshot") || cmd.Int("links") > 0 {
+				req.Extras = &client.ExtrasOptions{Links: int(cmd.Int("links"))}
+				if cmd.Bool("screenshot") {
+					req.Extras.ImageLinks = screenshotImageLinks
+				}
 			}
 			// Build context options
-			if cmd.Bool("context") || cmd.Int("context-max-chars") > 0 {
+			if cmd.Bool("context") || cmd.Int("context-max-chars") > 0 || cmd.Int("context-max-bytes") > 0 {
 				if cmd.Int("context-max-chars") > 0 {
 					req.Context = &client.ContextOptions{MaxCharacters: int(cmd.Int("context-max-chars"))}
 				} else {
@@ -390,20 +1208,535 @@ func contentsCmd() *cli.Command {
 				}
 			}
 
-			result, err := c.GetContents(ctx, req)
+			maxTokens := int(cmd.Int("max-tokens"))
+			if maxTokens < 0 {
+				return fmt.Errorf("max-tokens must not be negative")
+			}
+			if cmd.Int("max-chars-total") < 0 {
+				return fmt.Errorf("max-chars-total must not be negative")
+			}
+			if maxTokens > 0 && req.Text == nil {
+				// The local context is assembled from page text
+				req.Text = true
+			}
+
+			if cmd.Bool("diff") {
+				// Diffing needs fresh text, so always livecrawl
+				if req.Text == nil {
+					req.Text = true
+				}
+				live := 0
+				req.MaxAgeHours = &live
+			}
+
+			extra, err := parseExtraFields(cmd)
 			if err != nil {
 				return err
 			}
+			req.ExtraFields = extra
+
+			batchSize, err := contentsBatchSize(cmd)
+			if err != nil {
+				return err
+			}
+			concurrency := int(cmd.Int("concurrency"))
+			if concurrency < 1 {
+				return fmt.Errorf("concurrency must be at least 1")
+			}
+			if concurrency > 1 && batchSize == 0 {
+				// One request per URL, so a slow crawl only holds up its own page
+				batchSize = 1
+			}
+
+			if !cmd.Root().Bool("yes") {
+				calls := 1
+				if batchSize > 0 {
+					calls = (len(req.IDs) + batchSize - 1) / batchSize
+				}
+				if err := confirmExpensive(len(req.IDs), contentOptions(req.Text, req.Summary, req.Highlights), calls); err != nil {
+					return err
+				}
+			}
+
+			if err := echoRequest(cmd, req); err != nil {
+				return err
+			}
+			ctx, cancel := withTimeout(ctx, cmd)
+			defer cancel()
+			failed := newFailures(cmd)
+			result, err := getContentsBatched(ctx, c, req, batchSize, concurrency, failed)
+			if err != nil {
+				return timeoutErr(ctx, err)
+			}
+
+			if cmd.Bool("text-only-successful") {
+				if failed := dropFailedResults(result); len(failed) > 0 {
+					fmt.Fprintf(os.Stderr, "Skipped %d failed URL(s): %s\n", len(failed), strings.Join(failed, ", "))
+				}
+			}
+
+			if maxTokens > 0 {
+				var included int
+				result.Context, included = assembleContext(result.Results, maxTokens, estimateTokens)
+				fmt.Fprintf(os.Stderr, "Context: %d of %d sources fit in %d tokens\n", included, len(result.Results), maxTokens)
+			}
+			if maxBytes := int(cmd.Int("context-max-bytes")); maxBytes > 0 {
+				result.Context = truncateBytes(result.Context, maxBytes)
+			}
+
+			if cmd.Bool("diff") {
+				if outputFile == "" {
+					return errors.Join(printContentsDiff(os.Stdout, result), failed.err())
+				}
+				var buf bytes.Buffer
+				err := printContentsDiff(&buf, result)
+				return errors.Join(err, writeOutputFile(cmd, buf.Bytes()), failed.err())
+			}
+			applyMaxCharsTotal(cmd, result.Results)
 
-			return printOutput(cmd, result)
+			if dir := cmd.String("split-output"); dir != "" {
+				paths, err := writeSplitOutput(cmd, dir, result)
+				if err != nil {
+					return err
+				}
+				var buf bytes.Buffer
+				for _, path := range paths {
+					fmt.Fprintln(&buf, path)
+				}
+				return errors.Join(writeOutput(cmd, buf.Bytes(), usePager(cmd)), failed.err())
+			}
+
+			return errors.Join(printOutput(cmd, result), failed.err())
 		},
 	}
 }
 
+// searchContentsOptions builds the contents requested alongside search or
+// find-similar results from the text, summary and highlights flags, or nil if
+// none are set
+func searchContentsOptions(cmd *cli.Command) (*client.ContentsOptions, error) {
+	full, err := fullContents(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	hasTextOpts := full["text"] || cmd.Bool("text") || cmd.Int("text-max-chars") > 0 || cmd.Bool("text-include-html") || cmd.String("text-verbosity") != ""
+	hasSummaryOpts := full["summary"] || cmd.Bool("summary") || cmd.String("summary-query") != "" || cmd.String("summary-schema") != ""
+	wantHighlights := full["highlights"] || cmd.Bool("highlights")
+	if !hasTextOpts && !wantHighlights && !hasSummaryOpts && !cmd.Bool("metadata") {
+		return nil, nil
+	}
+	contents := &client.ContentsOptions{Metadata: cmd.Bool("metadata")}
+
+	if hasTextOpts {
+		if cmd.Int("text-max-chars") > 0 || cmd.Bool("text-include-html") || cmd.String("text-verbosity") != "" {
+			contents.Text = &client.TextOptions{
+				MaxCharacters:   int(cmd.Int("text-max-chars")),
+				IncludeHtmlTags: cmd.Bool("text-include-html"),
+				Verbosity:       cmd.String("text-verbosity"),
+			}
+		} else {
+			contents.Text = true
+		}
+	}
+	if wantHighlights {
+		contents.Highlights = true
+	}
+	if hasSummaryOpts {
+		if cmd.String("summary-query") != "" || cmd.String("summary-schema") != "" {
+			opts := &client.SummaryOptions{Query: cmd.String("summary-query")}
+			if schema := cmd.String("summary-schema"); schema != "" {
+				schemaObj, err := parseSummarySchema(schema)
+				if err != nil {
+					return nil, err
+				}
+				opts.Schema = schemaObj
+			}
+			contents.Summary = opts
+		} else {
+			contents.Summary = true
+		}
+	}
+	return contents, nil
+}
+
+// fullContents returns the content options enabled by search --full, as
+// configured by full_contents in the config file. Returns an empty set if
+// --full is not set.
+func fullContents(cmd *cli.Command) (map[string]bool, error) {
+	enabled := make(map[string]bool)
+	if !cmd.Bool("full") {
+		return enabled, nil
+	}
+	opts, err := config.GetFullContents()
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		switch opt {
+		case "text", "summary", "highlights":
+			enabled[opt] = true
+		default:
+			return nil, fmt.Errorf("invalid full_contents option %q in config: must be text, summary or highlights", opt)
+		}
+	}
+	return enabled, nil
+}
+
+// fileArgFlags are the flags whose value may be "@path" to read it from a
+// file, or "-" to read it from stdin, so long prompts and schemas don't need
+// shell quoting
+var fileArgFlags = []string{"summary-query", "summary-schema"}
+
+// resolveFileArgs replaces "@path" and "-" values of fileArgFlags with the
+// contents they name. Only one flag can read stdin, including --merge,
+// --compare and search --stdin.
+func resolveFileArgs(cmd *cli.Command) error {
+	var stdinFlag string
+	if cmd.Bool("stdin") {
+		stdinFlag = "stdin"
+	}
+	for _, name := range []string{"merge", "compare"} {
+		if cmd.String(name) == "-" {
+			if stdinFlag != "" {
+				return fmt.Errorf("--%s and --%s can't both read from stdin", stdinFlag, name)
+			}
+			stdinFlag = name
+		}
+	}
+
+	for _, name := range fileArgFlags {
+		value := cmd.String(name)
+		var data []byte
+		var err error
+		switch {
+		case value == "-":
+			if stdinFlag != "" {
+				return fmt.Errorf("--%s and --%s can't both read from stdin", stdinFlag, name)
+			}
+			stdinFlag = name
+			data, err = io.ReadAll(os.Stdin)
+		case strings.HasPrefix(value, "@"):
+			data, err = os.ReadFile(value[1:])
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read --%s: %w", name, err)
+		}
+		if err := cmd.Set(name, strings.TrimSpace(string(data))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSummarySchema parses --summary-schema, which must be a JSON object. A
+// schema with properties but no type is given "type": "object", and one with
+// neither draws a warning, since the API can't do much with it.
+func parseSummarySchema(schema string) (map[string]any, error) {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(schema), &obj); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, fmt.Errorf("invalid summary-schema JSON: %w", err)
+		}
+		return nil, fmt.Errorf("summary-schema must be a JSON object, like {\"type\": \"object\", \"properties\": {...}}")
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("summary-schema must be a JSON object, like {\"type\": \"object\", \"properties\": {...}}")
+	}
+
+	_, hasType := obj["type"]
+	_, hasProperties := obj["properties"]
+	switch {
+	case hasProperties && !hasType:
+		obj["type"] = "object"
+	case !hasProperties && !hasType:
+		fmt.Fprintln(os.Stderr, "warning: summary-schema has no \"type\" or \"properties\" and may not be a JSON Schema")
+	}
+	return obj, nil
+}
+
+// preferCacheMaxAgeHours is the max age sent by contents --prefer-cache: long
+// enough that any cached copy is accepted
+const preferCacheMaxAgeHours = 24 * 365
+
+// applyFreshness sets the max age and livecrawl mode for --prefer-cache and
+// --force-live. The two are mutually exclusive, and neither can be combined
+// with an explicit --max-age-hours.
+func applyFreshness(cmd *cli.Command, req *client.ContentsRequest) error {
+	preferCache, forceLive := cmd.Bool("prefer-cache"), cmd.Bool("force-live")
+	if !preferCache && !forceLive {
+		return nil
+	}
+	if preferCache && forceLive {
+		return fmt.Errorf("--prefer-cache and --force-live are mutually exclusive")
+	}
+	if cmd.IsSet("max-age-hours") {
+		return fmt.Errorf("--max-age-hours can't be combined with --prefer-cache or --force-live")
+	}
+
+	hours := preferCacheMaxAgeHours
+	req.Livecrawl = "fallback"
+	if forceLive {
+		hours = 0
+		req.Livecrawl = "always"
+	}
+	req.MaxAgeHours = &hours
+	return nil
+}
+
+// screenshotImageLinks is the number of image URLs requested per page by
+// contents --screenshot
+const screenshotImageLinks = 5
+
+// normalizeURL prepends https:// to scheme-less URLs like "example.com/page"
+// and checks the result is a well-formed http(s) URL
+func normalizeURL(raw string) (string, error) {
+	s := strings.TrimSpace(raw)
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid URL %q: scheme must be http or https", raw)
+	}
+	if u.Host == "" || strings.ContainsAny(u.Host, " \t") {
+		return "", fmt.Errorf("invalid URL %q: missing or invalid host", raw)
+	}
+	return u.String(), nil
+}
+
+// excludedDomains combines --exclude-domains with the domains of the URLs
+// given to --exclude-source-domains-of, without duplicates
+func excludedDomains(cmd *cli.Command) ([]string, error) {
+	domains := cmd.StringSlice("exclude-domains")
+	for _, raw := range cmd.StringSlice("exclude-source-domains-of") {
+		u, err := normalizeURL(raw)
+		if err != nil {
+			return nil, fmt.Errorf("--exclude-source-domains-of: %w", err)
+		}
+		if domain := resultDomain(u); !slices.Contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+	return domains, nil
+}
+
+// normalizeURLs applies normalizeURL to each argument, failing on the first
+// invalid entry
+func normalizeURLs(args []string) ([]string, error) {
+	urls := make([]string, len(args))
+	for i, arg := range args {
+		u, err := normalizeURL(arg)
+		if err != nil {
+			return nil, err
+		}
+		urls[i] = u
+	}
+	return urls, nil
+}
+
+// requestedURLs maps normalized contents arguments back to what the user typed,
+// for arguments that were changed by normalization
+func requestedURLs(cmd *cli.Command) map[string]string {
+	requested := make(map[string]string)
+	for _, arg := range cmd.Args().Slice() {
+		if u, err := normalizeURL(arg); err == nil && u != arg {
+			requested[u] = arg
+		}
+	}
+	return requested
+}
+
+// contentsBatchSize returns the --batch-size value, or 0 if the flag isn't set
+func contentsBatchSize(cmd *cli.Command) (int, error) {
+	size := int(cmd.Int("batch-size"))
+	if cmd.IsSet("batch-size") && (size < 1 || size > client.MaxContentsIDs) {
+		return 0, fmt.Errorf("batch-size must be between 1 and %d", client.MaxContentsIDs)
+	}
+	return size, nil
+}
+
+// getContentsBatched fetches contents for req.IDs in batches of at most
+// batchSize IDs, up to concurrency batches at a time, and merges the responses
+// in input order. A batchSize of 0 sends all IDs in a single request.
+//
+// Failed batches are added to failed. If the failure policy lets the command
+// carry on, the batch's URLs get error statuses and the other batches'
+// results are kept; otherwise the batches still running are cancelled and the
+// error is returned.
+func getContentsBatched(ctx context.Context, c *client.Client, req *client.ContentsRequest, batchSize, concurrency int, failed *failures) (*client.ContentsResponse, error) {
+	if batchSize == 0 || len(req.IDs) <= batchSize {
+		return c.GetContents(ctx, req)
+	}
+
+	type batchResult struct {
+		label string
+		ids   []string
+		resp  *client.ContentsResponse
+		err   error
+	}
+	results := make([]batchResult, (len(req.IDs)+batchSize-1)/batchSize)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		stopOnce sync.Once
+		stopErr  error
+	)
+	sem := make(chan struct{}, concurrency)
+	for i := range results {
+		start := i * batchSize
+		end := min(start+batchSize, len(req.IDs))
+		batch := *req
+		batch.IDs = req.IDs[start:end]
+		results[i].label = fmt.Sprintf("batch %d-%d", start+1, end)
+		results[i].ids = batch.IDs
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].err = ctx.Err()
+			continue
+		}
+		// A slot can free up at the same moment a fatal error cancels the
+		// run, and select picks either case then
+		if err := ctx.Err(); err != nil {
+			<-sem
+			results[i].err = err
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resp, err := c.GetContents(ctx, &batch)
+			results[i].resp, results[i].err = resp, err
+			if err != nil && failed.stops(err) {
+				stopOnce.Do(func() {
+					stopErr = fmt.Errorf("%s: %w", results[i].label, err)
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if stopErr != nil {
+		return nil, stopErr
+	}
+
+	merged := &client.ContentsResponse{}
+	for _, res := range results {
+		if res.err != nil {
+			if err := failed.add(res.label, res.err); err != nil {
+				return nil, err
+			}
+			merged.Statuses = append(merged.Statuses, failedStatuses(res.ids, res.err)...)
+			continue
+		}
+		resp := res.resp
+		merged.Results = append(merged.Results, resp.Results...)
+		merged.Statuses = append(merged.Statuses, resp.Statuses...)
+		if merged.RequestID == "" {
+			merged.RequestID = resp.RequestID
+		}
+		if resp.CostDollars != nil {
+			if merged.CostDollars == nil {
+				merged.CostDollars = &client.CostDollars{}
+			}
+			merged.CostDollars.Total += resp.CostDollars.Total
+		}
+		if resp.Context != "" {
+			if merged.Context != "" {
+				merged.Context += "\n\n"
+			}
+			merged.Context += resp.Context
+		}
+	}
+	return merged, nil
+}
+
+// dropFailedResults removes results whose status, matched by ID, is anything
+// other than success. Results without a status are kept. It returns the IDs of
+// all failed statuses.
+func dropFailedResults(resp *client.ContentsResponse) []string {
+	var failed []string
+	failedIDs := make(map[string]bool)
+	for _, st := range resp.Statuses {
+		if st.Status != "success" {
+			failed = append(failed, st.ID)
+			failedIDs[st.ID] = true
+		}
+	}
+
+	kept := resp.Results[:0]
+	for _, r := range resp.Results {
+		if !failedIDs[r.ID] {
+			kept = append(kept, r)
+		}
+	}
+	resp.Results = kept
+	return failed
+}
+
+// batchFailedTag is the error tag of the statuses failedStatuses makes up for
+// a batch that failed as a whole
+const batchFailedTag = "BATCH_FAILED"
+
+// failedStatuses returns an error status for each ID of a batch that failed as a whole
+func failedStatuses(ids []string, err error) []client.ContentStatus {
+	contentErr := &client.ContentError{Tag: batchFailedTag}
+	var statusErr *client.StatusError
+	if errors.As(err, &statusErr) {
+		contentErr.HTTPStatusCode = statusErr.StatusCode
+	}
+
+	statuses := make([]client.ContentStatus, len(ids))
+	for i, id := range ids {
+		statuses[i] = client.ContentStatus{ID: id, Status: "error", Error: contentErr}
+	}
+	return statuses
+}
+
+// warnFailedContents prints a warning listing the URLs whose contents
+// couldn't be fetched. URLs of batches that failed as a whole are left out,
+// as the batch failure is reported already.
+func warnFailedContents(statuses []client.ContentStatus) {
+	var failed []string
+	for _, st := range statuses {
+		if st.Status == "success" || (st.Error != nil && st.Error.Tag == batchFailedTag) {
+			continue
+		}
+		desc := st.ID
+		if st.Error != nil && st.Error.Tag != "" {
+			desc += " (" + st.Error.Tag + ")"
+		}
+		failed = append(failed, desc)
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: failed to fetch contents for %d URL(s): %s\n", len(failed), strings.Join(failed, ", "))
+	}
+}
+
 func configureCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "configure",
 		Usage: "Configure exa CLI settings (API key)",
+		UsageText: `Examples:
+  exa configure
+  exa configure --profile client-a --test`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "test",
+				Usage: "Validate the API key with a small search request before saving",
+			},
+		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			fmt.Print("Enter your Exa API key: ")
 
@@ -419,18 +1752,78 @@ func configureCmd() *cli.Command {
 				return fmt.Errorf("API key cannot be empty")
 			}
 
-			cfg := &config.Config{APIKey: key}
+			if cmd.Bool("test") {
+				if err := testAPIKey(ctx, cmd, key); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: API key %s failed validation: %s\n", maskKey(key), strings.ReplaceAll(err.Error(), key, maskKey(key)))
+					if !confirm("Save it anyway?") {
+						return fmt.Errorf("API key not saved")
+					}
+				} else {
+					fmt.Println("API key is valid")
+				}
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			// Save the key where GetAPIKey will read it: the profile from
+			// --profile, or else default_profile
+			profile := cfg.Profile()
+			if profile == "" {
+				cfg.APIKey = key
+			} else {
+				if cfg.Profiles == nil {
+					cfg.Profiles = make(map[string]config.Profile)
+				}
+				cfg.Profiles[profile] = config.Profile{APIKey: key}
+			}
 			if err := config.Save(cfg); err != nil {
 				return err
 			}
 
 			path, _ := config.Path()
-			fmt.Printf("API key saved to %s\n", path)
+			if profile != "" {
+				fmt.Printf("API key saved to profile %q in %s\n", profile, path)
+			} else {
+				fmt.Printf("API key saved to %s\n", path)
+			}
 			return nil
 		},
 	}
 }
 
+// testAPIKey makes a minimal search request to confirm key is accepted
+func testAPIKey(ctx context.Context, cmd *cli.Command, key string) error {
+	c, err := newClientWithKey(cmd, key)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, cmd)
+	defer cancel()
+	_, err = c.Search(ctx, &client.SearchRequest{Query: "exa", NumResults: 1})
+	return timeoutErr(ctx, err)
+}
+
+// maskKey hides all but the first and last few characters of an API key
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + strings.Repeat("*", len(key)-8) + key[len(key)-4:]
+}
+
+// confirm asks a yes/no question on stdin and reports whether the answer was yes
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
 func completionCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "completion",
@@ -464,6 +1857,80 @@ func completionCmd() *cli.Command {
 	}
 }
 
+func configCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Locate or edit the config file",
+		Commands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the profiles in the config file, marking the default",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					cfg, err := config.Load()
+					if err != nil {
+						return err
+					}
+					names := cfg.ProfileNames()
+					if len(names) == 0 {
+						fmt.Fprintln(os.Stderr, "no profiles configured (add one with exa configure --profile NAME)")
+						return nil
+					}
+					for _, name := range names {
+						if name == cfg.DefaultProfile {
+							fmt.Printf("%s (default)\n", name)
+						} else {
+							fmt.Println(name)
+						}
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "path",
+				Usage: "Print the config file path",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					path, err := config.Path()
+					if err != nil {
+						return err
+					}
+					fmt.Println(path)
+					return nil
+				},
+			},
+			{
+				Name:  "edit",
+				Usage: "Open the config file in $EDITOR, creating it from a template if needed",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					path, err := config.Path()
+					if err != nil {
+						return err
+					}
+					if _, err := config.Create(); err != nil {
+						return err
+					}
+
+					editor := os.Getenv("VISUAL")
+					if editor == "" {
+						editor = os.Getenv("EDITOR")
+					}
+					if editor == "" {
+						editor = defaultEditor
+					}
+					args := strings.Fields(editor)
+					e := exec.Command(args[0], append(args[1:], path)...)
+					e.Stdin = os.Stdin
+					e.Stdout = os.Stdout
+					e.Stderr = os.Stderr
+					if err := e.Run(); err != nil {
+						return fmt.Errorf("editor %q failed: %w", editor, err)
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
 func versionCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "version",
@@ -484,10 +1951,13 @@ _exa_completions() {
     cur="${COMP_WORDS[COMP_CWORD]}"
     prev="${COMP_WORDS[COMP_CWORD-1]}"
 
-    commands="search contents configure completion version help"
-    global_opts="--api-key --output -o --quiet -q --help -h"
-    search_opts="--type -t --num-results -n --text --text-max-chars --text-include-html --text-verbosity --highlights -H --summary -s --summary-query --summary-schema --include-domains -i --exclude-domains -x --start-published-date --end-published-date --category -c --max-age-hours"
-    contents_opts="--text -t --text-max-chars --text-include-html --text-verbosity --highlights -H --summary -s --summary-query --summary-schema --subpages -p --subpage-target --max-age-hours --livecrawl-timeout --context -C --context-max-chars"
+    commands="search contents find-similar similar answer research configure config cache completion version help"
+    global_opts="--api-key --output -o --quiet -q --yes -y --verbose --log-format --attempt-timeout --toon-header --no-pager --no-meta --idempotency --api-key-file --signing-secret --signature-header --csv-bom --project --ca-cert --insecure-skip-verify --jq --template-file --concurrency-limit --config --echo-request --json-root --estimate-tokens --cache-dir --also-json --also-csv --toon-fallback --fail-fast --best-effort --max-retries --retry-backoff --timeout --locale --base-url --profile --output-file -O --color --no-color --retries-verbose --backoff --append --help -h"
+    search_opts="--type -t --num-results -n --text --text-max-chars --text-include-html --text-verbosity --highlights -H --summary -s --summary-query --summary-schema --include-domains -i --exclude-domains -x --start-published-date --end-published-date --category -c --max-age-hours --set --set-json --start-index --new-only --full --include-domain-glob --exclude-domain-glob --domains-only --compare --show-scores --score-precision --score-as-percent --score-bars --columns-from-schema --pdf-only --merge --show-lengths --max-nodes --show-related --with-metadata --metadata --first-paragraph --highlight-terms --sort --totals --max-chars-total --min-published --max-published --keep-undated --exclude-source-domains-of --stdin --continue-on-error --fail-on-empty --results-per-query --total-limit --checkpoint --links"
+    similar_opts="--num-results -n --text --text-max-chars --text-include-html --text-verbosity --highlights -H --summary -s --summary-query --summary-schema --include-domains -i --exclude-domains -x --start-published-date --end-published-date --show-scores --score-precision --score-as-percent --start-index --with-metadata --set --set-json --with-contents --batch-size --concurrency"
+    answer_opts="--text"
+    research_opts="--depth"
+    contents_opts="--text -t --text-max-chars --text-include-html --text-verbosity --highlights -H --summary -s --summary-query --summary-schema --subpages -p --subpage-target --max-age-hours --livecrawl-timeout --context -C --context-max-chars --batch-size --set --set-json --diff --context-max-bytes --toc --screenshot --prefer-cache --force-live --max-tokens --columns-from-schema --text-only-successful --inline-status --metadata --first-paragraph --highlight-terms --split-output --max-chars-total --concurrency --links"
 
     case "${COMP_WORDS[1]}" in
         search|s)
@@ -498,6 +1968,26 @@ _exa_completions() {
             COMPREPLY=( $(compgen -W "${contents_opts}" -- ${cur}) )
             return 0
             ;;
+        find-similar|similar)
+            COMPREPLY=( $(compgen -W "${similar_opts}" -- ${cur}) )
+            return 0
+            ;;
+        answer)
+            COMPREPLY=( $(compgen -W "${answer_opts}" -- ${cur}) )
+            return 0
+            ;;
+        research)
+            COMPREPLY=( $(compgen -W "${research_opts}" -- ${cur}) )
+            return 0
+            ;;
+        config)
+            COMPREPLY=( $(compgen -W "list path edit" -- ${cur}) )
+            return 0
+            ;;
+        cache)
+            COMPREPLY=( $(compgen -W "info clear --older-than" -- ${cur}) )
+            return 0
+            ;;
         completion)
             COMPREPLY=( $(compgen -W "bash zsh fish" -- ${cur}) )
             return 0
@@ -524,7 +2014,13 @@ _exa() {
         's:Search the web using Exa'
         'contents:Get contents from URLs'
         'c:Get contents from URLs'
+        'find-similar:Find pages similar to a URL'
+        'similar:Find pages similar to a URL'
+        'answer:Get an answer to a question with citations'
+        'research:Research a topic and summarize the sources'
         'configure:Configure exa CLI settings'
+        'config:Locate or edit the config file'
+        'cache:Inspect or clear the local cache'
         'completion:Generate shell completion scripts'
         'version:Show detailed version information'
         'help:Shows a list of commands or help for one command'
@@ -532,8 +2028,48 @@ _exa() {
 
     _arguments -C \
         '--api-key[Exa API key]:key:' \
-        '(-o --output)'{-o,--output}'[Output format]:format:(table json toon)' \
+        '(-o --output)'{-o,--output}'[Output format]:format:(table json json-stable jsonl csv toon report mermaid markdown)' \
         '(-q --quiet)'{-q,--quiet}'[Quiet mode]' \
+        '(-y --yes)'{-y,--yes}'[Skip cost warnings and confirmations]' \
+        '--verbose[Log diagnostic information]' \
+        '--log-format[Log format]:format:(text json)' \
+        '--attempt-timeout[Timeout per HTTP attempt]:duration:' \
+        '--toon-header[Prepend TOON schema comment]' \
+        '--no-pager[Disable pager]' \
+        '--no-meta[Omit meta from JSON output]' \
+        '--idempotency[Send Idempotency-Key header]' \
+        '--api-key-file[Read API key from file]:file:_files' \
+        '--signing-secret[HMAC signing secret]:secret:' \
+        '--signature-header[Signature header name]:header:' \
+        '--csv-bom[Write a UTF-8 BOM before CSV output]' \
+        '--project[Result fields to keep]:fields:' \
+        '--ca-cert[Extra root CA PEM file]:file:_files' \
+        '--insecure-skip-verify[Disable TLS verification]' \
+        '--jq[Filter JSON output with jq]:expr:' \
+        '--template-file[Go template file for output]:file:_files' \
+        '--concurrency-limit[Max concurrent requests]:count:' \
+        '--config[Config file path]:file:_files' \
+        '--echo-request[Include request in JSON output]' \
+        '--json-root[Top-level key for JSON output]:key:' \
+        '--estimate-tokens[Estimate output tokens]' \
+        '--cache-dir[Cache directory]:dir:_files -/' \
+        '--also-json[Also write JSON to file]:file:_files' \
+        '--also-csv[Also write CSV to file]:file:_files' \
+        '--toon-fallback[Fall back to JSON if TOON encoding fails]' \
+        '--fail-fast[Stop at the first failed API call]' \
+        '--best-effort[Carry on past failed API calls (default)]' \
+        '--max-retries[Retries on 429 and 5xx errors]:count:' \
+        '--retry-backoff[Wait before the first retry]:duration:' \
+        '--timeout[Overall request timeout]:duration:' \
+        '--locale[Number format locale for tables]:locale:' \
+        '--base-url[API base URL]:url:' \
+        '--profile[Config file profile]:profile:' \
+        '(-O --output-file)'{-O,--output-file}'[Write output to a file]:file:_files' \
+        '--color[Color output]:mode:(auto always never)' \
+        '--no-color[Disable color output]' \
+        '--retries-verbose[Log each retry to stderr]' \
+        '--backoff[Retry wait strategy]:strategy:(full-jitter equal-jitter exponential constant)' \
+        '--append[Append to the output file]' \
         '(-h --help)'{-h,--help}'[Show help]' \
         '1:command:->command' \
         '*::arg:->args'
@@ -560,8 +2096,45 @@ _exa() {
                         '*'{-x,--exclude-domains}'[Exclude domains]:domain:' \
                         '--start-published-date[Start date]:date:' \
                         '--end-published-date[End date]:date:' \
-                        '(-c --category)'{-c,--category}'[Category]:category:(company people tweet news "research paper" "personal site" "financial report")' \
+                        '(-c --category)'{-c,--category}'[Category]:category:(company people tweet news "research paper" pdf "personal site" "financial report")' \
                         '--max-age-hours[Max age in hours]:hours:' \
+                        '*--set[Set extra request field (key=value)]:field:' \
+                        '*--set-json[Set extra request field (key=json)]:field:' \
+                        '--start-index[First result number in table]:index:' \
+                        '--new-only[Only show unseen results]' \
+                        '--full[Include text, summary and highlights]' \
+                        '*--include-domain-glob[Keep hosts matching glob]:pattern:' \
+                        '*--exclude-domain-glob[Drop hosts matching glob]:pattern:' \
+                        '--domains-only[List result domains by count]' \
+                        '--compare[Diff against saved JSON results]:file:_files' \
+                        '--show-scores[Show score column]' \
+                        '--score-precision[Decimal places for scores]:digits:' \
+                        '--score-as-percent[Show scores as percentages]' \
+                        '--score-bars[Show score bars]' \
+                        '--columns-from-schema[CSV columns from summary schema]' \
+                        '--pdf-only[Only keep PDF results]' \
+                        '--merge[Merge with saved results file]:file:_files' \
+                        '--show-lengths[Show text length columns]' \
+                        '--max-nodes[Max nodes in mermaid graph]:count:' \
+                        '--show-related[Suggest related searches]' \
+                        '--with-metadata[Tab-separated quiet output]' \
+                        '--metadata[Request page metadata]' \
+                        '--first-paragraph[Show first paragraph only]' \
+                        '--highlight-terms[Highlight query words]' \
+                        '--sort[Result order]:order:(relevance date)' \
+                        '--totals[Add a totals row]' \
+                        '--max-chars-total[Total text budget across results]:chars:' \
+                        '--min-published[Drop results published before date]:date:' \
+                        '--max-published[Drop results published after date]:date:' \
+                        '--keep-undated[Keep undated results when filtering by date]' \
+                        '*--exclude-source-domains-of[Exclude the domain of a URL]:url:' \
+                        '--stdin[Read queries from stdin]' \
+                        '--continue-on-error[Keep going past failed queries]' \
+                        '--fail-on-empty[Exit 6 when nothing is found]' \
+                        '--results-per-query[Results for each --stdin query]:n:' \
+                        '--total-limit[Cap on results across --stdin queries]:n:' \
+                        '--checkpoint[Save and resume paging progress]:file:_files' \
+                        '--links[Links per page, drawn as mermaid edges]:count:' \
                         '*:query:'
                     ;;
                 contents|c)
@@ -580,8 +2153,73 @@ _exa() {
                         '--livecrawl-timeout[Livecrawl timeout in ms]:timeout:' \
                         '(-C --context)'{-C,--context}'[Return combined context]' \
                         '--context-max-chars[Max chars for context]:chars:' \
+                        '--batch-size[URLs per request]:size:' \
+                        '*--set[Set extra request field (key=value)]:field:' \
+                        '*--set-json[Set extra request field (key=json)]:field:' \
+                        '--diff[Diff against cached version]' \
+                        '--context-max-bytes[Max bytes for context]:bytes:' \
+                        '--toc[Add table of contents]' \
+                        '--screenshot[Include page image URLs]' \
+                        '--prefer-cache[Prefer cached content]' \
+                        '--force-live[Always livecrawl]' \
+                        '--max-tokens[Token budget for local context]:tokens:' \
+                        '--columns-from-schema[CSV columns from summary schema]' \
+                        '--text-only-successful[Omit failed URLs]' \
+                        '--inline-status[Omit statuses array from JSON]' \
+                        '--metadata[Request page metadata]' \
+                        '--first-paragraph[Show first paragraph only]' \
+                        '--highlight-terms[Highlight summary query words]' \
+                        '--split-output[Write one file per result]:dir:_files -/' \
+                        '--max-chars-total[Total text budget across results]:chars:' \
+                        '--concurrency[Batches fetched at once]:count:' \
+                        '--links[Links to extract per page]:count:' \
                         '*:url:_urls'
                     ;;
+                find-similar|similar)
+                    _arguments \
+                        '(-n --num-results)'{-n,--num-results}'[Number of results]:num:' \
+                        '--text[Include full text content]' \
+                        '--text-max-chars[Max chars for text]:chars:' \
+                        '--text-include-html[Include HTML tags]' \
+                        '--text-verbosity[Text verbosity]:verbosity:(compact standard full)' \
+                        '(-H --highlights)'{-H,--highlights}'[Include highlights]' \
+                        '(-s --summary)'{-s,--summary}'[Include AI summary]' \
+                        '--summary-query[Custom query for summary]:query:' \
+                        '--summary-schema[JSON schema for summary]:schema:' \
+                        '*'{-i,--include-domains}'[Include domains]:domain:' \
+                        '*'{-x,--exclude-domains}'[Exclude domains]:domain:' \
+                        '--start-published-date[Start date]:date:' \
+                        '--end-published-date[End date]:date:' \
+                        '--show-scores[Show score column]' \
+                        '--score-precision[Decimal places for scores]:digits:' \
+                        '--score-as-percent[Show scores as percentages]' \
+                        '--start-index[First result number in table]:index:' \
+                        '--with-metadata[Tab-separated quiet output]' \
+                        '*--set[Set extra request field (key=value)]:field:' \
+                        '*--set-json[Set extra request field (key=json)]:field:' \
+                        '--with-contents[Fetch contents with separate requests]' \
+                        '--batch-size[URLs per contents request]:size:' \
+                        '--concurrency[Contents requests at once]:count:' \
+                        '1:url:_urls'
+                    ;;
+                answer)
+                    _arguments \
+                        '--text[Include citation text]' \
+                        '*:question:'
+                    ;;
+                research)
+                    _arguments \
+                        '--depth[Results to gather]:depth:' \
+                        '*:topic:'
+                    ;;
+                config)
+                    _arguments '1:action:(list path edit)'
+                    ;;
+                cache)
+                    _arguments \
+                        '--older-than[Only delete entries older than this]:duration:' \
+                        '1:action:(info clear)'
+                    ;;
                 completion)
                     _arguments '1:shell:(bash zsh fish)'
                     ;;
@@ -603,15 +2241,61 @@ complete -c exa -n __fish_use_subcommand -a search -d 'Search the web using Exa'
 complete -c exa -n __fish_use_subcommand -a s -d 'Search the web using Exa'
 complete -c exa -n __fish_use_subcommand -a contents -d 'Get contents from URLs'
 complete -c exa -n __fish_use_subcommand -a c -d 'Get contents from URLs'
+complete -c exa -n __fish_use_subcommand -a find-similar -d 'Find pages similar to a URL'
+complete -c exa -n __fish_use_subcommand -a similar -d 'Find pages similar to a URL'
+complete -c exa -n __fish_use_subcommand -a answer -d 'Get an answer to a question with citations'
+complete -c exa -n __fish_use_subcommand -a research -d 'Research a topic and summarize the sources'
 complete -c exa -n __fish_use_subcommand -a configure -d 'Configure exa CLI settings'
+complete -c exa -n __fish_use_subcommand -a config -d 'Locate or edit the config file'
+complete -c exa -n __fish_use_subcommand -a cache -d 'Inspect or clear the local cache'
 complete -c exa -n __fish_use_subcommand -a completion -d 'Generate shell completion scripts'
 complete -c exa -n __fish_use_subcommand -a version -d 'Show detailed version information'
 complete -c exa -n __fish_use_subcommand -a help -d 'Shows help'
 
 # Global options
 complete -c exa -l api-key -d 'Exa API key'
-complete -c exa -s o -l output -d 'Output format' -a 'table json toon'
+complete -c exa -s o -l output -d 'Output format' -a 'table json json-stable jsonl csv toon report mermaid markdown'
 complete -c exa -s q -l quiet -d 'Quiet mode'
+complete -c exa -s y -l yes -d 'Skip cost warnings and confirmations'
+complete -c exa -l verbose -d 'Log diagnostic information'
+complete -c exa -l log-format -d 'Log format' -a 'text json'
+complete -c exa -l attempt-timeout -d 'Timeout per HTTP attempt'
+complete -c exa -l toon-header -d 'Prepend TOON schema comment'
+complete -c exa -l no-pager -d 'Disable pager'
+complete -c exa -l no-meta -d 'Omit meta from JSON output'
+complete -c exa -l idempotency -d 'Send Idempotency-Key header'
+complete -c exa -l api-key-file -r -F -d 'Read API key from file'
+complete -c exa -l signing-secret -d 'HMAC signing secret'
+complete -c exa -l signature-header -d 'Signature header name'
+complete -c exa -l csv-bom -d 'Write a UTF-8 BOM before CSV output'
+complete -c exa -l project -d 'Result fields to keep'
+complete -c exa -l ca-cert -r -F -d 'Extra root CA PEM file'
+complete -c exa -l insecure-skip-verify -d 'Disable TLS verification'
+complete -c exa -l jq -d 'Filter JSON output with jq'
+complete -c exa -l template-file -r -F -d 'Go template file for output'
+complete -c exa -l concurrency-limit -d 'Max concurrent requests'
+complete -c exa -l config -r -F -d 'Config file path'
+complete -c exa -l echo-request -d 'Include request in JSON output'
+complete -c exa -l json-root -d 'Top-level key for JSON output'
+complete -c exa -l estimate-tokens -d 'Estimate output tokens'
+complete -c exa -l cache-dir -r -d 'Cache directory' -a '(__fish_complete_directories)'
+complete -c exa -l also-json -r -F -d 'Also write JSON to file'
+complete -c exa -l also-csv -r -F -d 'Also write CSV to file'
+complete -c exa -l toon-fallback -d 'Fall back to JSON if TOON encoding fails'
+complete -c exa -l fail-fast -d 'Stop at the first failed API call'
+complete -c exa -l best-effort -d 'Carry on past failed API calls (default)'
+complete -c exa -l max-retries -d 'Retries on 429 and 5xx errors'
+complete -c exa -l retry-backoff -d 'Wait before the first retry'
+complete -c exa -l timeout -d 'Overall request timeout'
+complete -c exa -l locale -d 'Number format locale for tables'
+complete -c exa -l base-url -d 'API base URL'
+complete -c exa -l profile -d 'Config file profile'
+complete -c exa -s O -l output-file -r -F -d 'Write output to a file'
+complete -c exa -l color -d 'Color output' -a 'auto always never'
+complete -c exa -l no-color -d 'Disable color output'
+complete -c exa -l retries-verbose -d 'Log each retry to stderr'
+complete -c exa -l backoff -d 'Retry wait strategy' -a 'full-jitter equal-jitter exponential constant'
+complete -c exa -l append -d 'Append to the output file'
 complete -c exa -s h -l help -d 'Show help'
 
 # Search options
@@ -629,33 +2313,606 @@ complete -c exa -n '__fish_seen_subcommand_from search s' -s i -l include-domain
 complete -c exa -n '__fish_seen_subcommand_from search s' -s x -l exclude-domains -d 'Exclude domains'
 complete -c exa -n '__fish_seen_subcommand_from search s' -l start-published-date -d 'Start date'
 complete -c exa -n '__fish_seen_subcommand_from search s' -l end-published-date -d 'End date'
-complete -c exa -n '__fish_seen_subcommand_from search s' -s c -l category -d 'Category' -a 'company people tweet news "research paper" "personal site" "financial report"'
+complete -c exa -n '__fish_seen_subcommand_from search s' -s c -l category -d 'Category' -a 'company people tweet news "research paper" pdf "personal site" "financial report"'
 complete -c exa -n '__fish_seen_subcommand_from search s' -l max-age-hours -d 'Max age in hours'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l set -d 'Set extra request field (key=value)'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l set-json -d 'Set extra request field (key=json)'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l start-index -d 'First result number in table'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l new-only -d 'Only show unseen results'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l full -d 'Include text, summary and highlights'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l include-domain-glob -d 'Keep hosts matching glob'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l exclude-domain-glob -d 'Drop hosts matching glob'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l domains-only -d 'List result domains by count'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l compare -r -F -d 'Diff against saved JSON results'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l show-scores -d 'Show score column'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l score-precision -d 'Decimal places for scores'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l score-as-percent -d 'Show scores as percentages'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l score-bars -d 'Show score bars'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l columns-from-schema -d 'CSV columns from summary schema'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l pdf-only -d 'Only keep PDF results'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l merge -r -F -d 'Merge with saved results file'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l show-lengths -d 'Show text length columns'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l max-nodes -d 'Max nodes in mermaid graph'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l show-related -d 'Suggest related searches'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l with-metadata -d 'Tab-separated quiet output'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l metadata -d 'Request page metadata'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l first-paragraph -d 'Show first paragraph only'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l highlight-terms -d 'Highlight query words'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l sort -d 'Result order' -a 'relevance date'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l totals -d 'Add a totals row'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l max-chars-total -d 'Total text budget across results'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l min-published -d 'Drop results published before date'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l max-published -d 'Drop results published after date'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l keep-undated -d 'Keep undated results when filtering by date'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l exclude-source-domains-of -d 'Exclude the domain of a URL'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l stdin -d 'Read queries from stdin'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l continue-on-error -d 'Keep going past failed queries'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l fail-on-empty -d 'Exit 6 when nothing is found'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l results-per-query -d 'Results for each --stdin query'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l total-limit -d 'Cap on results across --stdin queries'
+complete -c exa -n '__fish_seen_subcommand_from search s' -l checkpoint -d 'Save and resume paging progress' -r -F
+complete -c exa -n '__fish_seen_subcommand_from search s' -l links -d 'Links per page, drawn as mermaid edges'
+
+# Contents options
+complete -c exa -n '__fish_seen_subcommand_from contents c' -s t -l text -d 'Include full text content'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l text-max-chars -d 'Max chars for text'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l text-include-html -d 'Include HTML tags'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l text-verbosity -d 'Text verbosity' -a 'compact standard full'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -s H -l highlights -d 'Include highlights'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -s s -l summary -d 'Include AI summary'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l summary-query -d 'Custom query for summary'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l summary-schema -d 'JSON schema for summary'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -s p -l subpages -d 'Number of subpages'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l subpage-target -d 'Subpage target keywords'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l max-age-hours -d 'Max age in hours'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l livecrawl-timeout -d 'Livecrawl timeout in ms'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -s C -l context -d 'Return combined context'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l context-max-chars -d 'Max chars for context'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l batch-size -d 'URLs per request'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l set -d 'Set extra request field (key=value)'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l set-json -d 'Set extra request field (key=json)'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l diff -d 'Diff against cached version'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l context-max-bytes -d 'Max bytes for context'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l toc -d 'Add table of contents'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l screenshot -d 'Include page image URLs'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l prefer-cache -d 'Prefer cached content'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l force-live -d 'Always livecrawl'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l max-tokens -d 'Token budget for local context'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l columns-from-schema -d 'CSV columns from summary schema'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l text-only-successful -d 'Omit failed URLs'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l inline-status -d 'Omit statuses array from JSON'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l metadata -d 'Request page metadata'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l first-paragraph -d 'Show first paragraph only'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l highlight-terms -d 'Highlight summary query words'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l split-output -r -d 'Write one file per result' -a '(__fish_complete_directories)'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l max-chars-total -d 'Total text budget across results'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l concurrency -d 'Batches fetched at once'
+complete -c exa -n '__fish_seen_subcommand_from contents c' -l links -d 'Links to extract per page'
+
+# Find-similar options
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -s n -l num-results -d 'Number of results'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l text -d 'Include full text content'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l text-max-chars -d 'Max chars for text'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l text-include-html -d 'Include HTML tags'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l text-verbosity -d 'Text verbosity' -a 'compact standard full'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -s H -l highlights -d 'Include highlights'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -s s -l summary -d 'Include AI summary'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l summary-query -d 'Custom query for summary'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l summary-schema -d 'JSON schema for summary'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -s i -l include-domains -d 'Include domains'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -s x -l exclude-domains -d 'Exclude domains'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l start-published-date -d 'Start date'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l end-published-date -d 'End date'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l show-scores -d 'Show score column'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l score-precision -d 'Decimal places for scores'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l score-as-percent -d 'Show scores as percentages'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l start-index -d 'First result number in table'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l with-metadata -d 'Tab-separated quiet output'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l set -d 'Set extra request field (key=value)'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l set-json -d 'Set extra request field (key=json)'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l with-contents -d 'Fetch contents with separate requests'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l batch-size -d 'URLs per contents request'
+complete -c exa -n '__fish_seen_subcommand_from find-similar similar' -l concurrency -d 'Contents requests at once'
+
+# Answer options
+complete -c exa -n '__fish_seen_subcommand_from answer' -l text -d 'Include citation text'
+
+# Research options
+complete -c exa -n '__fish_seen_subcommand_from research' -l depth -d 'Results to gather'
+
+# Config subcommands
+complete -c exa -n '__fish_seen_subcommand_from config' -a 'list path edit' -d 'Config action'
+
+# Cache subcommands
+complete -c exa -n '__fish_seen_subcommand_from cache' -a 'info clear' -d 'Cache action'
+complete -c exa -n '__fish_seen_subcommand_from cache' -l older-than -d 'Only delete entries older than this'
+
+# Completion subcommands
+complete -c exa -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish' -d 'Shell type'
+`
+
+// filterDomainGlobs removes results whose host doesn't match any include
+// pattern or matches an exclude pattern. Patterns use path.Match syntax and are
+// tried against the host both with and without a leading "www.".
+func filterDomainGlobs(resp *client.SearchResponse, include, exclude []string) error {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil
+	}
+	for _, pattern := range append(include, exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid domain glob %q: %w", pattern, err)
+		}
+	}
+
+	matchAny := func(host string, patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, host); ok {
+				return true
+			}
+			if ok, _ := path.Match(pattern, strings.TrimPrefix(host, "www.")); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	kept := resp.Results[:0]
+	for _, r := range resp.Results {
+		u, err := url.Parse(r.URL)
+		if err != nil {
+			continue
+		}
+		host := strings.ToLower(u.Hostname())
+		if len(include) > 0 && !matchAny(host, include) {
+			continue
+		}
+		if matchAny(host, exclude) {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	resp.Results = kept
+	return nil
+}
+
+// isPDF reports whether a result is a PDF document, judged by its URL path
+func isPDF(r client.SearchResult) bool {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(strings.ToLower(u.Path), ".pdf")
+}
+
+// filterPDFResults removes results that aren't PDF documents
+func filterPDFResults(resp *client.SearchResponse) {
+	kept := resp.Results[:0]
+	for _, r := range resp.Results {
+		if isPDF(r) {
+			kept = append(kept, r)
+		}
+	}
+	resp.Results = kept
+}
+
+// domainCount is the number of results from a single domain
+type domainCount struct {
+	Domain  string `json:"domain" toon:"domain"`
+	Results int    `json:"results" toon:"results"`
+}
+
+// domainCounts is a list of domains ranked by result count
+type domainCounts []domainCount
+
+// resultDomain returns the host of a result URL without a leading "www.",
+// or "" if the URL can't be parsed
+func resultDomain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// countDomains ranks the unique domains in resp by number of results. Ties keep
+// the order in which domains first appear in the results.
+func countDomains(resp *client.SearchResponse) domainCounts {
+	var counts domainCounts
+	index := make(map[string]int)
+	for _, r := range resp.Results {
+		domain := resultDomain(r.URL)
+		if domain == "" {
+			continue
+		}
+		if i, ok := index[domain]; ok {
+			counts[i].Results++
+			continue
+		}
+		index[domain] = len(counts)
+		counts = append(counts, domainCount{Domain: domain, Results: 1})
+	}
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].Results > counts[j].Results
+	})
+	return counts
+}
+
+// seenCacheNamespace is the cache namespace holding result URLs already
+// reported by --new-only, keyed by query
+const seenCacheNamespace = cache.SeenNamespace
+
+// seenResults is the set of result URLs previously reported for a query
+type seenResults struct {
+	Query string   `json:"query"`
+	URLs  []string `json:"urls"`
+}
+
+// filterNewResults removes results already reported for query in a previous
+// run, then records the current URLs as seen.
+func filterNewResults(query string, resp *client.SearchResponse) error {
+	var seen seenResults
+	if _, err := cache.Load(seenCacheNamespace, query, &seen); err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(seen.URLs))
+	for _, u := range seen.URLs {
+		known[u] = true
+	}
+
+	fresh := resp.Results[:0]
+	for _, r := range resp.Results {
+		if !known[r.URL] {
+			fresh = append(fresh, r)
+			known[r.URL] = true
+			seen.URLs = append(seen.URLs, r.URL)
+		}
+	}
+	resp.Results = fresh
+
+	seen.Query = query
+	return cache.Save(seenCacheNamespace, query, seen)
+}
+
+// rawStringSliceFlag is a repeatable string flag that, unlike cli.StringSliceFlag,
+// does not split values on commas. Used for values that may contain JSON.
+type rawStringSliceFlag = cli.FlagBase[[]string, cli.NoConfig, rawStringSlice]
+
+type rawStringSlice struct {
+	values *[]string
+}
+
+func (rawStringSlice) Create(v []string, p *[]string, _ cli.NoConfig) cli.Value {
+	*p = v
+	return &rawStringSlice{values: p}
+}
+
+func (rawStringSlice) ToString(v []string) string {
+	return strings.Join(v, ", ")
+}
+
+func (s *rawStringSlice) Set(v string) error {
+	*s.values = append(*s.values, v)
+	return nil
+}
+
+func (s *rawStringSlice) String() string {
+	if s.values == nil {
+		return ""
+	}
+	return strings.Join(*s.values, ", ")
+}
+
+func (s *rawStringSlice) Get() any {
+	return *s.values
+}
+
+// parseExtraFields collects the --set and --set-json flags into a map of
+// request fields to merge into the request body.
+func parseExtraFields(cmd *cli.Command) (map[string]any, error) {
+	fields := make(map[string]any)
+
+	for _, kv := range cmd.Value("set").([]string) {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --set %q: expected key=value", kv)
+		}
+		fields[key] = value
+	}
+	for _, kv := range cmd.Value("set-json").([]string) {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --set-json %q: expected key=<json>", kv)
+		}
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, fmt.Errorf("invalid --set-json value for %q: %w", key, err)
+		}
+		fields[key] = v
+	}
+
+	return fields, nil
+}
+
+// contentOptions names the content options enabled by a search's contents or
+// a contents request
+func contentOptions(text, summary, highlights any) []string {
+	var opts []string
+	if text != nil {
+		opts = append(opts, "text")
+	}
+	if summary != nil {
+		opts = append(opts, "summary")
+	}
+	if highlights != nil {
+		opts = append(opts, "highlights")
+	}
+	return opts
+}
+
+// confirmExpensive guards a request for pages × content options when that
+// exceeds the configured threshold. On a terminal it asks for confirmation,
+// returning an error if declined; otherwise it only prints a warning.
+func confirmExpensive(pages int, opts []string, calls int) error {
+	threshold, err := config.GetWarnThreshold()
+	if err != nil {
+		return err
+	}
+	if pages*len(opts) <= threshold {
+		return nil
+	}
+	what := fmt.Sprintf("%d pages with %s in %d API call(s)", pages, strings.Join(opts, ", "), calls)
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintf(os.Stderr, "warning: requesting %s may be slow and costly (use --yes to silence)\n", what)
+		return nil
+	}
+	if !confirm(fmt.Sprintf("This will request %s, which may be slow and costly. Continue?", what)) {
+		return fmt.Errorf("request cancelled")
+	}
+	return nil
+}
+
+// outputMeta holds response metadata, emitted under "meta" in JSON output
+type outputMeta struct {
+	AutopromptString   string  `json:"autopromptString,omitempty"`
+	ResolvedSearchType string  `json:"resolvedSearchType,omitempty"`
+	CostDollars        float64 `json:"costDollars,omitempty"`
+	RequestID          string  `json:"requestId,omitempty"`
+	ElapsedMs          int64   `json:"elapsedMs"`
+}
+
+// jsonEnvelope separates result data from response metadata in JSON output
+type jsonEnvelope struct {
+	Results  any                    `json:"results"`
+	Context  string                 `json:"context,omitempty"`
+	Statuses []client.ContentStatus `json:"statuses,omitempty"`
+	Request  any                    `json:"request,omitempty"`
+	Meta     *outputMeta            `json:"meta,omitempty"`
+}
+
+// contentsResult is a contents result joined with its fetch status
+type contentsResult struct {
+	client.SearchResult
+	Status string               `json:"status,omitempty"`
+	Error  *client.ContentError `json:"error,omitempty"`
+}
+
+// withStatuses joins each result with the status sharing its ID
+func withStatuses(resp *client.ContentsResponse) []contentsResult {
+	byID := make(map[string]client.ContentStatus, len(resp.Statuses))
+	for _, st := range resp.Statuses {
+		byID[st.ID] = st
+	}
+	results := make([]contentsResult, len(resp.Results))
+	for i, r := range resp.Results {
+		st := byID[r.ID]
+		results[i] = contentsResult{SearchResult: r, Status: st.Status, Error: st.Error}
+	}
+	return results
+}
+
+// echoRequest records the body of req on cmd, for newJSONEnvelope to include
+// in JSON output with --echo-request
+func echoRequest(cmd *cli.Command, req interface{ Body() (any, error) }) error {
+	body, err := req.Body()
+	if err != nil {
+		return err
+	}
+	if cmd.Metadata == nil {
+		cmd.Metadata = make(map[string]any)
+	}
+	cmd.Metadata["request"] = body
+	return nil
+}
+
+// answerOutput is an answer with the request that produced it, for JSON
+// output with --echo-request
+type answerOutput struct {
+	*client.AnswerResponse
+	Request any `json:"request,omitempty"`
+}
+
+// newJSONEnvelope wraps search and contents responses for JSON output. Other
+// values are returned unchanged.
+func newJSONEnvelope(cmd *cli.Command, v any) any {
+	meta := &outputMeta{ElapsedMs: elapsed().Milliseconds()}
+
+	var env jsonEnvelope
+	switch resp := v.(type) {
+	case *client.SearchResponse:
+		env.Results = resp.Results
+		meta.AutopromptString = resp.AutopromptString
+		meta.ResolvedSearchType = resp.ResolvedSearchType
+		meta.RequestID = resp.RequestID
+		if resp.CostDollars != nil {
+			meta.CostDollars = resp.CostDollars.Total
+		}
+	case *client.ContentsResponse:
+		env.Results = withStatuses(resp)
+		env.Context = resp.Context
+		if !cmd.Bool("inline-status") {
+			env.Statuses = resp.Statuses
+		}
+		meta.RequestID = resp.RequestID
+		if resp.CostDollars != nil {
+			meta.CostDollars = resp.CostDollars.Total
+		}
+	case *searchBatch:
+		out := make(searchBatchOutput, len(resp.Queries))
+		for i, q := range resp.Queries {
+			out[i] = toon.Field{Key: q.Query, Value: newJSONEnvelope(cmd, q.Response)}
+		}
+		return out
+	case *client.AnswerResponse:
+		if cmd.Root().Bool("echo-request") {
+			return answerOutput{resp, cmd.Metadata["request"]}
+		}
+		return v
+	default:
+		return v
+	}
+
+	if cmd.Root().Bool("echo-request") {
+		env.Request = cmd.Metadata["request"]
+	}
+	if !cmd.Root().Bool("no-meta") {
+		env.Meta = meta
+	}
+	return env
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printJSONStable prints v as indented JSON with every object's keys sorted
+// alphabetically, struct fields included, so output diffs cleanly between
+// runs. v is round-tripped through generic maps, which encoding/json always
+// writes in key order; numbers are kept verbatim.
+func printJSONStable(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	return printJSON(w, generic)
+}
+
+// searchRecord is a search result tagged with the query that produced it, as
+// written by --output jsonl
+type searchRecord struct {
+	Query string `json:"query"`
+	client.SearchResult
+}
+
+// printJSONL writes one compact JSON object per line for each result. Search
+// results carry a query field so streams from several runs can be merged
+// without losing provenance. Other values are written as a single line.
+func printJSONL(w io.Writer, cmd *cli.Command, v any) error {
+	fields, err := projectFields(cmd)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	switch resp := v.(type) {
+	case *client.SearchResponse:
+		return encodeSearchRecords(enc, cmd.Args().First(), resp.Results, fields)
+	case *searchBatch:
+		for _, q := range resp.Queries {
+			if err := encodeSearchRecords(enc, q.Query, q.Response.Results, fields); err != nil {
+				return err
+			}
+		}
+	case *client.ContentsResponse:
+		for _, r := range resp.Results {
+			var record any = r
+			if fields != nil {
+				if record, err = projectResult(r, fields); err != nil {
+					return err
+				}
+			}
+			if err := enc.Encode(record); err != nil {
+				return err
+			}
+		}
+	default:
+		return enc.Encode(v)
+	}
+	return nil
+}
 
-# Contents options
-complete -c exa -n '__fish_seen_subcommand_from contents c' -s t -l text -d 'Include full text content'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -l text-max-chars -d 'Max chars for text'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -l text-include-html -d 'Include HTML tags'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -l text-verbosity -d 'Text verbosity' -a 'compact standard full'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -s H -l highlights -d 'Include highlights'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -s s -l summary -d 'Include AI summary'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -l summary-query -d 'Custom query for summary'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -l summary-schema -d 'JSON schema for summary'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -s p -l subpages -d 'Number of subpages'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -l subpage-target -d 'Subpage target keywords'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -l max-age-hours -d 'Max age in hours'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -l livecrawl-timeout -d 'Livecrawl timeout in ms'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -s C -l context -d 'Return combined context'
-complete -c exa -n '__fish_seen_subcommand_from contents c' -l context-max-chars -d 'Max chars for context'
+// encodeSearchRecords writes a searchRecord line for each result of query,
+// keeping only fields if it is non-nil
+func encodeSearchRecords(enc *json.Encoder, query string, results []client.SearchResult, fields []string) error {
+	for _, r := range results {
+		var record any = searchRecord{Query: query, SearchResult: r}
+		if fields != nil {
+			projected, err := projectResult(r, fields)
+			if err != nil {
+				return err
+			}
+			projected["query"] = query
+			record = projected
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-# Completion subcommands
-complete -c exa -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish' -d 'Shell type'
-`
+// jsonRoot returns the --json-root key, checking it can be used as a JSON
+// object key
+func jsonRoot(cmd *cli.Command) (string, error) {
+	if !cmd.Root().IsSet("json-root") {
+		return "", nil
+	}
+	key := cmd.Root().String("json-root")
+	if strings.TrimSpace(key) == "" {
+		return "", fmt.Errorf("--json-root must not be empty")
+	}
+	if !utf8.ValidString(key) {
+		return "", fmt.Errorf("--json-root must be valid UTF-8")
+	}
+	return key, nil
+}
 
-func printJSON(v any) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	return enc.Encode(v)
+// withJSONRoot nests v under the --json-root key, if set
+func withJSONRoot(cmd *cli.Command, v any) any {
+	key, err := jsonRoot(cmd)
+	if err != nil || key == "" {
+		return v
+	}
+	return map[string]any{key: v}
+}
+
+// validateOutputFlags checks output flags that would otherwise only fail after
+// the API request has been made
+func validateOutputFlags(cmd *cli.Command) error {
+	if _, err := projectFields(cmd); err != nil {
+		return err
+	}
+	if _, err := schemaColumns(cmd); err != nil {
+		return err
+	}
+	if _, err := jqQuery(cmd); err != nil {
+		return err
+	}
+	if _, err := jsonRoot(cmd); err != nil {
+		return err
+	}
+	if _, err := loadTemplate(cmd); err != nil {
+		return err
+	}
+	return nil
 }
 
 func getOutputFormat(cmd *cli.Command) string {
@@ -666,32 +2923,115 @@ func isQuietMode(cmd *cli.Command) bool {
 	return cmd.Root().Bool("quiet")
 }
 
-// truncate truncates a string to maxLen characters
+// truncate shortens s to at most maxLen terminal columns, ending it with
+// "...". It cuts on rune boundaries, so multibyte UTF-8 text is never split
+// mid-character, and counts wide CJK characters as two columns so truncated
+// cells line up.
 func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	if displayWidth(s) <= maxLen {
 		return s
 	}
-	return s[:maxLen-3] + "..."
+	limit := maxLen - 3
+	used := 0
+	for i, r := range s {
+		if used += runeWidth(r); used > limit {
+			return s[:i] + "..."
+		}
+	}
+	return s
+}
+
+// formatScore renders a relevance score using --score-precision decimal places,
+// as a percentage with --score-as-percent
+func formatScore(cmd *cli.Command, score float64) string {
+	precision := max(int(cmd.Int("score-precision")), 0)
+	if cmd.Bool("score-as-percent") {
+		return strconv.FormatFloat(score*100, 'f', precision, 64) + "%"
+	}
+	return strconv.FormatFloat(score, 'f', precision, 64)
+}
+
+// scoreBarWidth is the number of cells in a --score-bars bar
+const scoreBarWidth = 5
+
+// scoreRange returns the lowest and highest score among results
+func scoreRange(results []client.SearchResult) (lo, hi float64) {
+	for i, r := range results {
+		if i == 0 || r.Score < lo {
+			lo = r.Score
+		}
+		if i == 0 || r.Score > hi {
+			hi = r.Score
+		}
+	}
+	return lo, hi
+}
+
+// scoreBar draws score as a bar of block characters, scaled so lo is empty
+// and hi is full. When every score is equal the bar is full.
+func scoreBar(score, lo, hi float64) string {
+	frac := 1.0
+	if hi > lo {
+		frac = (score - lo) / (hi - lo)
+	}
+	filled := int(math.Round(frac * scoreBarWidth))
+	return strings.Repeat("█", filled) + strings.Repeat("░", scoreBarWidth-filled)
 }
 
-func printSearchTable(cmd *cli.Command, resp *client.SearchResponse) {
-	useColor := isTerminal()
+// firstParagraph returns the text before the first blank line of s, trimmed
+func firstParagraph(s string) string {
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+	para, _, _ := strings.Cut(s, "\n\n")
+	return strings.TrimSpace(para)
+}
 
-	// Disable color globally if not a TTY
-	if !useColor {
-		color.NoColor = true
+// truncateBytes trims s to at most maxBytes bytes, backing up to the start of a
+// rune so the result is still valid UTF-8
+func truncateBytes(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
 	}
+	return strings.ToValidUTF8(s[:cut], "")
+}
+
+func printSearchTable(w io.Writer, cmd *cli.Command, resp *client.SearchResponse) {
+	useColor := colorEnabled()
+	color.NoColor = !useColor
 
 	headerFmt := color.New(color.FgWhite, color.Bold).SprintFunc()
 	numFmt := color.New(color.FgCyan).SprintFunc()
 
 	// Determine which columns to show based on flags
-	showText := cmd.Bool("text")
-	showSummary := cmd.Bool("summary") || cmd.String("summary-query") != "" || cmd.String("summary-schema") != ""
-
-	// Build dynamic column headers
+	full, _ := fullContents(cmd)
+	showText := cmd.Bool("text") || full["text"]
+	showSummary := cmd.Bool("summary") || cmd.String("summary-query") != "" || cmd.String("summary-schema") != "" || full["summary"]
+	showScores := cmd.Bool("show-scores") || cmd.Bool("score-bars")
+	showBars := cmd.Bool("score-bars") && useColor
+	showLengths := cmd.Bool("show-lengths")
+	showMetadata := cmd.Bool("metadata")
+	showTotals := cmd.Bool("totals") && (showScores || showLengths)
+	lo, hi := scoreRange(resp.Results)
+
+	// Build dynamic column headers, remembering where the numeric ones are
+	// for the --totals row
 	var headers []any
 	headers = append(headers, "#", "Title", "URL")
+	scoreCol, lengthCol := -1, -1
+	if showScores {
+		scoreCol = len(headers)
+		headers = append(headers, "Score")
+	}
+	if showLengths {
+		lengthCol = len(headers)
+		headers = append(headers, "Chars", "Words")
+	}
+	if showMetadata {
+		headers = append(headers, "Site", "Lang")
+	}
 	if showText {
 		headers = append(headers, "Text")
 	}
@@ -702,21 +3042,33 @@ func printSearchTable(cmd *cli.Command, resp *client.SearchResponse) {
 		headers = append(headers, "Published")
 	}
 
-	tbl := table.New(headers...)
+	tbl := table.New(headers...).WithWriter(w).WithWidthFunc(visibleWidth)
 	tbl.WithHeaderFormatter(func(format string, vals ...interface{}) string {
 		return headerFmt(fmt.Sprintf(format, vals...))
 	})
 
+	var terms *regexp.Regexp
+	if cmd.Bool("highlight-terms") && useColor {
+		terms = termPattern(cmd.Args().First())
+	}
+
 	// Use shorter title when showing text/summary columns
 	titleMaxLen := 55
 	if showText || showSummary {
 		titleMaxLen = 40
 	}
 
+	var scoreSum float64
+	var charSum, wordSum int
+	startIndex := int(cmd.Int("start-index"))
 	for i, r := range resp.Results {
-		title := truncate(r.Title, titleMaxLen)
+		title := resultTitle(r)
+		if isPDF(r) {
+			title = "[PDF] " + title
+		}
+		title = truncate(title, titleMaxLen)
 		url := truncate(r.URL, 45)
-		num := fmt.Sprintf("%d", i+1)
+		num := fmt.Sprintf("%d", startIndex+i)
 		if useColor {
 			num = numFmt(num)
 		}
@@ -724,19 +3076,49 @@ func printSearchTable(cmd *cli.Command, resp *client.SearchResponse) {
 		// Build row based on columns
 		var row []any
 		row = append(row, num, title, url)
+		if showScores {
+			score := displayScore(cmd, r.Score)
+			if showBars {
+				score = scoreBar(r.Score, lo, hi) + " " + score
+			}
+			row = append(row, score)
+		}
+		scoreSum += r.Score
+		if showLengths {
+			if r.Text == "" {
+				row = append(row, "-", "-")
+			} else {
+				chars, words := utf8.RuneCountInString(r.Text), len(strings.Fields(r.Text))
+				charSum += chars
+				wordSum += words
+				row = append(row, displayInt(chars), displayInt(words))
+			}
+		}
+		if showMetadata {
+			site, lang := "-", "-"
+			if m := r.Metadata; m != nil {
+				site = cmp.Or(truncate(m.SiteName, 20), site)
+				lang = cmp.Or(m.Language, lang)
+			}
+			row = append(row, site, lang)
+		}
 		if showText {
-			text := truncate(r.Text, 60)
+			text := r.Text
+			if cmd.Bool("first-paragraph") {
+				text = firstParagraph(text)
+			}
+			text = truncate(text, 60)
 			if text == "" {
 				text = "-"
 			}
-			row = append(row, text)
+			row = append(row, highlightTerms(text, terms))
 		}
 		if showSummary {
 			summary := truncate(r.Summary, 60)
 			if summary == "" {
 				summary = "-"
 			}
-			row = append(row, summary)
+			row = append(row, highlightTerms(summary, terms))
 		}
 		if !showText && !showSummary {
 			date := r.PublishedDate
@@ -747,100 +3129,593 @@ func printSearchTable(cmd *cli.Command, resp *client.SearchResponse) {
 		}
 		tbl.AddRow(row...)
 	}
+
+	// Aggregate row: average score and total lengths, blank elsewhere
+	if showTotals && len(resp.Results) > 0 {
+		row := make([]any, len(headers))
+		for i := range row {
+			row[i] = ""
+		}
+		row[1] = headerFmt("Total")
+		if scoreCol >= 0 {
+			row[scoreCol] = "avg " + displayScore(cmd, scoreSum/float64(len(resp.Results)))
+		}
+		if lengthCol >= 0 {
+			row[lengthCol], row[lengthCol+1] = displayInt(charSum), displayInt(wordSum)
+		}
+		tbl.AddRow(row...)
+	}
+	tbl.Print()
+}
+
+// printSearchReport prints a readable one-shot snapshot of a search: the
+// autoprompt, the results table, the domain distribution, the resolved search
+// type and a cost/timing footer
+func printSearchReport(w io.Writer, cmd *cli.Command, resp *client.SearchResponse) {
+	color.NoColor = !colorEnabled()
+	labelFmt := color.New(color.Bold).SprintFunc()
+
+	if query := cmd.Args().First(); query != "" {
+		fmt.Fprintf(w, "%s %s\n", labelFmt("Query:"), query)
+	}
+	if resp.AutopromptString != "" {
+		fmt.Fprintf(w, "%s %s\n", labelFmt("Autoprompt:"), resp.AutopromptString)
+	}
+	fmt.Fprintln(w)
+
+	printSearchTable(w, cmd, resp)
+
+	if counts := countDomains(resp); len(counts) > 0 {
+		const maxDomains = 5
+		var parts []string
+		for _, d := range counts[:min(len(counts), maxDomains)] {
+			parts = append(parts, fmt.Sprintf("%s (%s)", d.Domain, displayInt(d.Results)))
+		}
+		if len(counts) > maxDomains {
+			parts = append(parts, fmt.Sprintf("+%s more", displayInt(len(counts)-maxDomains)))
+		}
+		fmt.Fprintf(w, "\n%s %s\n", labelFmt("Domains:"), strings.Join(parts, ", "))
+	}
+	if resp.ResolvedSearchType != "" {
+		fmt.Fprintf(w, "%s %s\n", labelFmt("Search type:"), resp.ResolvedSearchType)
+	}
+
+	footer := fmt.Sprintf("%s results in %s", displayInt(len(resp.Results)), elapsed().Round(time.Millisecond))
+	if resp.CostDollars != nil {
+		footer += ", cost " + displayCost(resp.CostDollars.Total)
+	}
+	fmt.Fprintf(w, "\n%s\n", footer)
+}
+
+// printSearchQuiet prints one URL per line, or with --with-metadata the
+// tab-separated URL, title, score and published date
+func printSearchQuiet(w io.Writer, cmd *cli.Command, resp *client.SearchResponse) {
+	withMetadata := cmd.Bool("with-metadata")
+	for _, r := range resp.Results {
+		if !withMetadata {
+			fmt.Fprintln(w, r.URL)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.URL, tsvField(resultTitle(r)), formatScore(cmd, r.Score), r.PublishedDate)
+	}
+}
+
+// tsvField replaces tabs and newlines so s stays within one tab-separated field
+func tsvField(s string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+}
+
+func printDomainsTable(w io.Writer, counts domainCounts) {
+	color.NoColor = !colorEnabled()
+	headerFmt := color.New(color.FgWhite, color.Bold).SprintFunc()
+
+	tbl := table.New("#", "Domain", "Results").WithWriter(w).WithWidthFunc(displayWidth)
+	tbl.WithHeaderFormatter(func(format string, vals ...interface{}) string {
+		return headerFmt(fmt.Sprintf(format, vals...))
+	})
+	for i, d := range counts {
+		tbl.AddRow(i+1, d.Domain, displayInt(d.Results))
+	}
 	tbl.Print()
 }
 
-func printSearchQuiet(resp *client.SearchResponse) {
+func printDomainsQuiet(w io.Writer, counts domainCounts) {
+	for _, d := range counts {
+		fmt.Fprintln(w, d.Domain)
+	}
+}
+
+// slugger generates GitHub-style heading anchors, numbering duplicates the
+// way GitHub does ("summary", "summary-1", ...)
+type slugger map[string]int
+
+func (s slugger) slug(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	base := b.String()
+	n := s[base]
+	s[base]++
+	if n > 0 {
+		return fmt.Sprintf("%s-%d", base, n)
+	}
+	return base
+}
+
+// resultTitle is the title shown for a result: its own, or else the title
+// from its page metadata (--metadata)
+func resultTitle(r client.SearchResult) string {
+	if r.Title == "" && r.Metadata != nil {
+		return r.Metadata.Title
+	}
+	return r.Title
+}
+
+// resultHeading is the markdown heading used for a result with --toc
+func resultHeading(r client.SearchResult) string {
+	if title := resultTitle(r); title != "" {
+		return title
+	}
+	return r.URL
+}
+
+// printTOC prints a linked table of contents for the result sections. Anchors
+// are computed by walking the headings in the order printContentsMarkdown
+// emits them, so duplicate headings get the same suffixes GitHub assigns.
+func printTOC(w io.Writer, resp *client.ContentsResponse) {
+	slugs := slugger{}
+	slugs.slug("Contents")
+
+	fmt.Fprintln(w, "## Contents")
+	fmt.Fprintln(w)
 	for _, r := range resp.Results {
-		fmt.Println(r.URL)
+		heading := resultHeading(r)
+		fmt.Fprintf(w, "- [%s](#%s)\n", heading, slugs.slug(heading))
+		if r.Summary != "" {
+			slugs.slug("Summary")
+		}
+		if len(r.Highlights) > 0 {
+			slugs.slug("Highlights")
+		}
 	}
+	fmt.Fprintln(w)
 }
 
-func printContentsMarkdown(resp *client.ContentsResponse) {
+func printContentsMarkdown(w io.Writer, cmd *cli.Command, resp *client.ContentsResponse) {
+	if cmd.Bool("toc") {
+		printTOC(w, resp)
+	}
+
+	// Contents has no search query, so terms come from --summary-query
+	var terms *regexp.Regexp
+	if cmd.Bool("highlight-terms") && colorEnabled() {
+		terms = termPattern(cmd.String("summary-query"))
+	}
+
+	requested := requestedURLs(cmd)
 	for i, r := range resp.Results {
 		if i > 0 {
-			fmt.Println()
+			fmt.Fprintln(w)
 		}
-		fmt.Println("---")
-		fmt.Printf("title: %q\n", r.Title)
-		fmt.Printf("url: %s\n", r.URL)
-		if r.PublishedDate != "" {
-			fmt.Printf("date: %q\n", r.PublishedDate)
+		printResultMarkdown(w, cmd, r, requested[r.ID], 0, terms)
+	}
+}
+
+// printSearchMarkdown writes each search result as a markdown document, like
+// contents output, with its rank in the frontmatter
+func printSearchMarkdown(w io.Writer, cmd *cli.Command, resp *client.SearchResponse) {
+	var terms *regexp.Regexp
+	if cmd.Bool("highlight-terms") && colorEnabled() {
+		terms = termPattern(cmd.Args().First())
+	}
+
+	startIndex := int(cmd.Int("start-index"))
+	for i, r := range resp.Results {
+		if i > 0 {
+			fmt.Fprintln(w)
 		}
-		if r.Author != "" {
-			fmt.Printf("author: %q\n", r.Author)
+		printResultMarkdown(w, cmd, r, "", startIndex+i, terms)
+	}
+}
+
+// printResultMarkdown writes one result as a markdown document with YAML
+// frontmatter. requested is the URL as given on the command line, if it
+// differs from the result's. rank is the result's position in search output,
+// or 0 to leave it out. The score is included with --show-scores unless it's
+// zero, as it is for contents results.
+func printResultMarkdown(w io.Writer, cmd *cli.Command, r client.SearchResult, requested string, rank int, terms *regexp.Regexp) {
+	fmt.Fprintln(w, "---")
+	fmt.Fprintf(w, "title: %q\n", resultTitle(r))
+	fmt.Fprintf(w, "url: %s\n", r.URL)
+	if rank > 0 {
+		fmt.Fprintf(w, "rank: %d\n", rank)
+	}
+	if cmd.Bool("show-scores") && r.Score != 0 {
+		fmt.Fprintf(w, "score: %s\n", formatScore(cmd, r.Score))
+	}
+	if requested != "" {
+		fmt.Fprintf(w, "requested: %q\n", requested)
+	}
+	if r.PublishedDate != "" {
+		fmt.Fprintf(w, "date: %q\n", r.PublishedDate)
+	}
+	if r.Author != "" {
+		fmt.Fprintf(w, "author: %q\n", r.Author)
+	}
+	if isPDF(r) {
+		fmt.Fprintln(w, "type: pdf")
+	}
+	if m := r.Metadata; m != nil {
+		if m.SiteName != "" {
+			fmt.Fprintf(w, "site: %q\n", m.SiteName)
 		}
-		fmt.Println("---")
-		if r.Text != "" {
-			fmt.Println()
-			fmt.Println(r.Text)
+		if m.Description != "" {
+			fmt.Fprintf(w, "description: %q\n", m.Description)
 		}
-		if r.Summary != "" {
-			fmt.Println()
-			fmt.Println("## Summary")
-			fmt.Println()
-			fmt.Println(r.Summary)
+		if m.Language != "" {
+			fmt.Fprintf(w, "language: %q\n", m.Language)
 		}
-		if len(r.Highlights) > 0 {
-			fmt.Println()
-			fmt.Println("## Highlights")
-			fmt.Println()
-			for _, h := range r.Highlights {
-				fmt.Printf("- %s\n", h)
-			}
+	}
+	fmt.Fprintln(w, "---")
+	if cmd.Bool("toc") {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "# %s\n", resultHeading(r))
+	}
+	if r.Text != "" {
+		text := r.Text
+		if cmd.Bool("first-paragraph") {
+			text = firstParagraph(text)
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, highlightTerms(text, terms))
+	}
+	if r.Summary != "" {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "## Summary")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, highlightTerms(r.Summary, terms))
+	}
+	if len(r.Highlights) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "## Highlights")
+		fmt.Fprintln(w)
+		for _, h := range r.Highlights {
+			fmt.Fprintf(w, "- %s\n", h)
+		}
+	}
+	if images := resultImages(r); cmd.Bool("screenshot") && len(images) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "## Images")
+		fmt.Fprintln(w)
+		for _, img := range images {
+			fmt.Fprintf(w, "- %s\n", img)
+		}
+	}
+	if r.Extras != nil && len(r.Extras.Links) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "## Links")
+		fmt.Fprintln(w)
+		for _, link := range r.Extras.Links {
+			fmt.Fprintf(w, "- %s\n", link)
 		}
 	}
 }
 
-func printContentsQuiet(resp *client.ContentsResponse) {
+// resultImages returns the page image followed by any extracted image links
+func resultImages(r client.SearchResult) []string {
+	var images []string
+	if r.Image != "" {
+		images = append(images, r.Image)
+	}
+	if r.Extras != nil {
+		images = append(images, r.Extras.ImageLinks...)
+	}
+	return images
+}
+
+func printContentsQuiet(w io.Writer, resp *client.ContentsResponse) {
+	// With --context, the combined string is what RAG pipelines want
+	if resp.Context != "" {
+		fmt.Fprintln(w, resp.Context)
+		return
+	}
 	for i, r := range resp.Results {
 		if i > 0 {
-			fmt.Println()
+			fmt.Fprintln(w)
 		}
 		if r.Text != "" {
-			fmt.Println(r.Text)
+			fmt.Fprintln(w, r.Text)
 		}
 	}
 }
 
-func printTOON(v any) error {
+// errTOONEncode marks printTOON failures to encode the value, as opposed to
+// failures writing it, so --toon-fallback knows JSON output may still work
+var errTOONEncode = errors.New("failed to encode output as TOON")
+
+func printTOON(w io.Writer, v any, header bool, fields []string) error {
+	// The encoder writes an Object's fields in order, keeping a batch's
+	// queries in input order
+	if batch, ok := v.(searchBatchOutput); ok {
+		v = toon.NewObject(batch...)
+	}
 	encoded, err := toon.Marshal(v, toon.WithLengthMarkers(true))
 	if err != nil {
-		return err
+		return fmt.Errorf("%w (%T): %v; use --output json, or --toon-fallback to switch automatically", errTOONEncode, v, err)
+	}
+	if header {
+		fmt.Fprintln(w, toonHeader(fields))
 	}
-	_, err = os.Stdout.Write(encoded)
+	_, err = w.Write(encoded)
 	return err
 }
 
+// toonHeader describes the shape of result records as a comment line, e.g.
+// "# results[]{title:string,url:string,score?:number,...}". Optional fields
+// (omitempty) are marked with "?". If fields is non-nil only those fields are
+// described.
+func toonHeader(fields []string) string {
+	t := reflect.TypeFor[client.SearchResult]()
+	described := make([]string, 0, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("toon")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if fields != nil && !slices.Contains(fields, name) {
+			continue
+		}
+		if strings.Contains(opts, "omitempty") {
+			name += "?"
+		}
+		described = append(described, name+":"+toonTypeName(f.Type))
+	}
+	return "# results[]{" + strings.Join(described, ",") + "}"
+}
+
+// toonTypeName maps a Go type to the value type name used in toonHeader
+func toonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		return toonTypeName(t.Elem()) + "[]"
+	default:
+		return "object"
+	}
+}
+
 func printOutput(cmd *cli.Command, v any) error {
+	if err := writeAlsoFiles(cmd, v); err != nil {
+		return err
+	}
+
+	// Page human-readable output on a terminal, like git does
+	paged, estimate := usePager(cmd), cmd.Root().Bool("estimate-tokens")
+	if !paged && !estimate && outputFile == "" {
+		return renderOutput(os.Stdout, cmd, v)
+	}
+
+	var buf bytes.Buffer
+	if err := renderOutput(&buf, cmd, v); err != nil {
+		return err
+	}
+	if estimate {
+		// Count the text as emitted, without terminal colors
+		text := ansiEscape.ReplaceAllString(buf.String(), "")
+		fmt.Fprintf(os.Stderr, "Estimated tokens: ~%d (%d chars/token)\n", estimateTokens(text), charsPerToken)
+	}
+	return writeOutput(cmd, buf.Bytes(), paged)
+}
+
+// writeOutput writes rendered output to the --output-file path if set, or
+// else to stdout, through the pager if paged
+func writeOutput(cmd *cli.Command, out []byte, paged bool) error {
+	if outputFile != "" {
+		return writeOutputFile(cmd, out)
+	}
+	if paged {
+		return writePaged(out)
+	}
+	_, err := os.Stdout.Write(out)
+	return err
+}
+
+// writeOutputFile writes out to the --output-file path, replacing the file if
+// it exists. With --append it is added to the end of the file instead, less
+// the CSV header if the file already has content.
+func writeOutputFile(cmd *cli.Command, out []byte) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if cmd.Root().Bool("append") {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		if info, err := os.Stat(outputFile); err == nil && info.Size() > 0 && getOutputFormat(cmd) == "csv" && !isQuietMode(cmd) {
+			out = stripCSVHeader(cmd, out)
+		}
+	}
+
+	f, err := os.OpenFile(outputFile, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write --output-file: %w", err)
+	}
+	if _, err := f.Write(out); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write --output-file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write --output-file: %w", err)
+	}
+	return nil
+}
+
+// alsoFormats maps the --also-<format> flags to the format they write
+var alsoFormats = []struct{ flag, format string }{
+	{"also-json", "json"},
+	{"also-csv", "csv"},
+}
+
+// writeAlsoFiles writes v to each file named by an --also-<format> flag in
+// that format, alongside the primary output on stdout
+func writeAlsoFiles(cmd *cli.Command, v any) error {
+	for _, also := range alsoFormats {
+		path := cmd.Root().String(also.flag)
+		if path == "" {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := renderFormat(&buf, cmd, v, also.format); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("failed to write --%s file: %w", also.flag, err)
+		}
+	}
+	return nil
+}
+
+func renderOutput(w io.Writer, cmd *cli.Command, v any) error {
 	quiet := isQuietMode(cmd)
 	format := getOutputFormat(cmd)
 
+	// --jq always works on the JSON output, whatever the format
+	code, err := jqQuery(cmd)
+	if err != nil {
+		return err
+	}
+	if code != nil {
+		out, err := projectOutput(cmd, newJSONEnvelope(cmd, v))
+		if err != nil {
+			return err
+		}
+		return printJQ(w, code, out)
+	}
+
+	tmpl, err := loadTemplate(cmd)
+	if err != nil {
+		return err
+	}
+	if tmpl != nil {
+		return printTemplate(w, tmpl, v)
+	}
+
 	// Quiet mode overrides format for specific output types
 	if quiet {
 		switch resp := v.(type) {
 		case *client.SearchResponse:
-			printSearchQuiet(resp)
+			printSearchQuiet(w, cmd, resp)
+			return nil
+		case *searchBatch:
+			for i, q := range resp.Queries {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				printSearchQuiet(w, cmd, q.Response)
+			}
 			return nil
 		case *client.ContentsResponse:
-			printContentsQuiet(resp)
+			printContentsQuiet(w, resp)
+			return nil
+		case domainCounts:
+			printDomainsQuiet(w, resp)
+			return nil
+		case *client.AnswerResponse:
+			fmt.Fprintln(w, resp.Answer)
+			return nil
+		case *researchReport:
+			for _, r := range append(resp.Sources, resp.Related...) {
+				fmt.Fprintln(w, r.URL)
+			}
 			return nil
 		}
 	}
 
+	return renderFormat(w, cmd, v, format)
+}
+
+// renderFormat writes v in the given --output format
+func renderFormat(w io.Writer, cmd *cli.Command, v any, format string) error {
 	switch format {
 	case "json":
-		return printJSON(v)
+		out, err := projectOutput(cmd, newJSONEnvelope(cmd, v))
+		if err != nil {
+			return err
+		}
+		return printJSON(w, withJSONRoot(cmd, out))
+	case "json-stable":
+		out, err := projectOutput(cmd, newJSONEnvelope(cmd, v))
+		if err != nil {
+			return err
+		}
+		return printJSONStable(w, withJSONRoot(cmd, out))
+	case "jsonl":
+		return printJSONL(w, cmd, v)
+	case "csv":
+		return printCSV(w, cmd, v)
 	case "toon":
-		return printTOON(v)
+		fields, err := projectFields(cmd)
+		if err != nil {
+			return err
+		}
+		out, err := projectOutput(cmd, v)
+		if err != nil {
+			return err
+		}
+		err = printTOON(w, out, cmd.Root().Bool("toon-header"), fields)
+		if errors.Is(err, errTOONEncode) && cmd.Root().Bool("toon-fallback") {
+			fmt.Fprintf(os.Stderr, "warning: %s (%T), writing JSON instead\n", errTOONEncode, out)
+			return renderFormat(w, cmd, v, "json")
+		}
+		return err
+	case "markdown":
+		if resp, ok := v.(*client.SearchResponse); ok {
+			printSearchMarkdown(w, cmd, resp)
+			return nil
+		}
+		fallthrough
+	case "report":
+		if resp, ok := v.(*client.SearchResponse); ok {
+			printSearchReport(w, cmd, resp)
+			return nil
+		}
+		fallthrough
+	case "mermaid":
+		if resp, ok := v.(*client.SearchResponse); ok {
+			printSearchMermaid(w, cmd, resp)
+			return nil
+		}
+		fallthrough
 	default: // "table"
 		switch resp := v.(type) {
 		case *client.SearchResponse:
-			printSearchTable(cmd, resp)
+			printSearchTable(w, cmd, resp)
+			if cmd.Bool("show-related") {
+				printRelated(w, relatedQueries(cmd.Args().First(), resp.Results))
+			}
 		case *client.ContentsResponse:
-			printContentsMarkdown(resp)
+			printContentsMarkdown(w, cmd, resp)
+		case *searchBatch:
+			return printSearchBatch(w, cmd, resp, format)
+		case domainCounts:
+			printDomainsTable(w, resp)
+		case *client.AnswerResponse:
+			printAnswer(w, resp)
+		case *researchReport:
+			printResearchMarkdown(w, resp)
+		case *resultComparison:
+			printComparison(w, resp)
 		default:
-			return printJSON(v)
+			return printJSON(w, v)
 		}
 	}
 	return nil
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/urfave/cli/v3"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata with the current output")
+
+// goldenFormats are the --output formats each fixture is rendered in, plus
+// quiet mode
+var goldenFormats = []string{"table", "json", "json-stable", "jsonl", "csv", "toon", "report", "mermaid", "markdown", "quiet"}
+
+// goldenFixtures are the canned API responses in testdata/<name>.json, with
+// the command and arguments they are rendered for
+var goldenFixtures = []struct {
+	name    string
+	command []string
+	v       any
+}{
+	{"search", []string{"search", "rust async runtimes"}, &client.SearchResponse{}},
+	{"contents", []string{"contents", "https://tokio.rs/", "https://example.com/missing"}, &client.ContentsResponse{}},
+	{"answer", []string{"answer", "what is the most popular rust async runtime"}, &client.AnswerResponse{}},
+}
+
+func TestMain(m *testing.M) {
+	// Keep the elapsed time in JSON meta and report footers reproducible
+	elapsed = func() time.Duration { return 42 * time.Millisecond }
+	os.Exit(m.Run())
+}
+
+// TestGoldenOutput renders each fixture in every output format and compares
+// the result with testdata/<fixture>.<format>.golden. Run with -update to
+// rewrite the golden files after an intended output change.
+func TestGoldenOutput(t *testing.T) {
+	for _, fixture := range goldenFixtures {
+		loadFixture(t, filepath.Join("testdata", fixture.name+".json"), fixture.v)
+		for _, format := range goldenFormats {
+			t.Run(fixture.name+"/"+format, func(t *testing.T) {
+				args := []string{"--no-pager", "--output", format}
+				if format == "quiet" {
+					args = []string{"--no-pager", "--quiet"}
+				}
+				args = append(args, fixture.command...)
+
+				var buf bytes.Buffer
+				err := runCommand(t, args, func(cmd *cli.Command) error {
+					return renderOutput(&buf, cmd, fixture.v)
+				})
+				if err != nil {
+					// Unsupported combinations are part of the documented behavior
+					buf.Reset()
+					buf.WriteString("error: " + err.Error() + "\n")
+				}
+				checkGolden(t, filepath.Join("testdata", fixture.name+"."+format+".golden"), buf.Bytes())
+			})
+		}
+	}
+}
+
+// runCommand runs the CLI with args, calling action with the parsed
+// subcommand in place of the subcommand's own action, so nothing is sent to
+// the API
+func runCommand(t *testing.T, args []string, action func(cmd *cli.Command) error) error {
+	t.Helper()
+	t.Setenv("EXA_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	t.Setenv("EXA_CACHE_DIR", t.TempDir())
+
+	app := newApp()
+	for _, sub := range app.Commands {
+		sub.Action = func(ctx context.Context, cmd *cli.Command) error {
+			return action(cmd)
+		}
+	}
+	return app.Run(context.Background(), append([]string{"exa"}, args...))
+}
+
+// runCLI runs the CLI with args against the API at baseURL and returns what
+// it wrote as output (through --output-file) and to stderr
+func runCLI(t testing.TB, baseURL string, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+	t.Setenv("EXA_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	t.Setenv("EXA_CACHE_DIR", t.TempDir())
+	out := filepath.Join(t.TempDir(), "output")
+
+	args = append([]string{"exa", "--api-key", "test-key", "--base-url", baseURL, "--output-file", out, "--yes", "--no-pager"}, args...)
+	stderr = captureStderr(t, func() {
+		err = newApp().Run(context.Background(), args)
+	})
+	data, _ := os.ReadFile(out)
+	return string(data), stderr, err
+}
+
+// captureStderr returns what fn writes to os.Stderr
+func captureStderr(t testing.TB, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = orig }()
+
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+	fn()
+	_ = w.Close()
+	return <-done
+}
+
+// loadFixture decodes the JSON file at path into v
+func loadFixture(t *testing.T, path string, v any) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("failed to parse %s: %v", path, err)
+	}
+}
+
+// checkGolden compares got with the golden file at path, or rewrites the file
+// with -update
+func checkGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if bytes.Equal(got, want) {
+		return
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(want)),
+		B:        difflib.SplitLines(string(got)),
+		FromFile: path,
+		ToFile:   "output",
+		Context:  3,
+	})
+	t.Errorf("output differs from %s (run go test -update if the change is intended):\n%s", path, diff)
+}
@@ -0,0 +1,47 @@
+package main
+
+import "github.com/12458/exa-cli/internal/client"
+
+// mergeResults combines saved results with fresh ones, de-duplicating by URL.
+// Saved results keep their position; a fresh result with the same URL replaces
+// the saved copy, and new URLs are appended in their fetched order.
+func mergeResults(saved, fresh []client.SearchResult) []client.SearchResult {
+	merged := make([]client.SearchResult, 0, len(saved)+len(fresh))
+	index := make(map[string]int, len(saved)+len(fresh))
+	for _, r := range append(saved, fresh...) {
+		if i, ok := index[r.URL]; ok {
+			merged[i] = r
+			continue
+		}
+		index[r.URL] = len(merged)
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// mergeContents copies the text, highlights, summary, metadata and extras of
+// fetched contents into the results they were fetched for, matched by ID or
+// URL. The results keep their order, titles and scores; results without
+// fetched contents are left as they are.
+func mergeContents(results, fetched []client.SearchResult) {
+	byKey := make(map[string]client.SearchResult, 2*len(fetched))
+	for _, f := range fetched {
+		byKey[f.URL] = f
+		if f.ID != "" {
+			byKey[f.ID] = f
+		}
+	}
+	for i := range results {
+		f, ok := byKey[results[i].ID]
+		if !ok {
+			if f, ok = byKey[results[i].URL]; !ok {
+				continue
+			}
+		}
+		results[i].Text = f.Text
+		results[i].Highlights = f.Highlights
+		results[i].Summary = f.Summary
+		results[i].Metadata = f.Metadata
+		results[i].Extras = f.Extras
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/urfave/cli/v3"
+)
+
+// printSearchMermaid writes a Mermaid flowchart linking the query to the
+// domains of its results, with edges labelled by result count. Links returned
+// in result extras (search --links) add edges between domains already in the
+// graph. At most
+// --max-nodes domains are drawn, most frequent first.
+func printSearchMermaid(w io.Writer, cmd *cli.Command, resp *client.SearchResponse) {
+	counts := countDomains(resp)
+	if maxNodes := int(cmd.Int("max-nodes")); maxNodes > 0 && len(counts) > maxNodes {
+		counts = counts[:maxNodes]
+	}
+
+	nodes := make(map[string]string, len(counts))
+	fmt.Fprintln(w, "graph LR")
+	fmt.Fprintf(w, "    q[\"%s\"]\n", mermaidLabel(cmd.Args().First()))
+	for i, c := range counts {
+		id := fmt.Sprintf("d%d", i+1)
+		nodes[c.Domain] = id
+		fmt.Fprintf(w, "    q -->|%d| %s[\"%s\"]\n", c.Results, id, mermaidLabel(c.Domain))
+	}
+
+	seen := make(map[[2]string]bool)
+	for _, r := range resp.Results {
+		if r.Extras == nil {
+			continue
+		}
+		from, ok := nodes[resultDomain(r.URL)]
+		if !ok {
+			continue
+		}
+		for _, link := range r.Extras.Links {
+			to, ok := nodes[resultDomain(link)]
+			if !ok || to == from || seen[[2]string{from, to}] {
+				continue
+			}
+			seen[[2]string{from, to}] = true
+			fmt.Fprintf(w, "    %s -.-> %s\n", from, to)
+		}
+	}
+}
+
+// mermaidLabel escapes text for use inside a quoted Mermaid node label
+func mermaidLabel(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/urfave/cli/v3"
+)
+
+// runAppend runs the search command with args, writing resp through
+// printOutput in place of a search
+func runAppend(t *testing.T, resp *client.SearchResponse, args ...string) error {
+	t.Helper()
+	return runCommand(t, append(args, "search", "q"), func(cmd *cli.Command) error {
+		return printOutput(cmd, resp)
+	})
+}
+
+func TestAppendCSVSkipsHeader(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "results.csv")
+	first := &client.SearchResponse{Results: []client.SearchResult{{Title: "One", URL: "https://one.example/"}}}
+	second := &client.SearchResponse{Results: []client.SearchResult{{Title: "Two", URL: "https://two.example/"}}}
+
+	for _, resp := range []*client.SearchResponse{first, second} {
+		if err := runAppend(t, resp, "--output", "csv", "--csv-bom", "--output-file", out, "--append"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	if n := strings.Count(got, "title,url,"); n != 1 {
+		t.Errorf("got %d header rows, want 1:\n%s", n, got)
+	}
+	if n := strings.Count(got, utf8BOM); n != 1 || !strings.HasPrefix(got, utf8BOM) {
+		t.Errorf("got %d BOMs, want 1 at the start", n)
+	}
+	if !strings.Contains(got, "One,https://one.example/") || !strings.HasSuffix(got, "Two,https://two.example/,,,,,\n") {
+		t.Errorf("rows missing or out of order:\n%s", got)
+	}
+}
+
+func TestAppendJSONL(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "results.jsonl")
+	if err := os.WriteFile(out, []byte(`{"existing":true}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resp := &client.SearchResponse{Results: []client.SearchResult{{Title: "One", URL: "https://one.example/"}}}
+	if err := runAppend(t, resp, "--output", "jsonl", "--output-file", out, "--append"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(out)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 || lines[0] != `{"existing":true}` || !strings.Contains(lines[1], `"url":"https://one.example/"`) {
+		t.Errorf("got %q, want the existing line followed by the new record", lines)
+	}
+}
+
+func TestWithoutAppendReplacesFile(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "results.csv")
+	if err := os.WriteFile(out, []byte("old content\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resp := &client.SearchResponse{Results: []client.SearchResult{{Title: "One", URL: "https://one.example/"}}}
+	if err := runAppend(t, resp, "--output", "csv", "--output-file", out); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(out); strings.Contains(string(data), "old content") {
+		t.Errorf("file wasn't replaced:\n%s", data)
+	}
+}
+
+func TestAppendRejectsSingleDocumentFormats(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "results")
+	for _, format := range singleDocumentFormats {
+		err := runAppend(t, &client.SearchResponse{}, "--output", format, "--output-file", out, "--append")
+		if err == nil || !strings.Contains(err.Error(), "single document") {
+			t.Errorf("--output %s --append: got %v, want a single document error", format, err)
+		}
+	}
+	if err := runAppend(t, &client.SearchResponse{}, "--output", "csv", "--append"); err == nil {
+		t.Error("--append without --output-file: got nil error")
+	}
+}
+
+// nestedMapOutput nests a map TOON can't encode (its keys aren't strings)
+// inside the results
+var nestedMapOutput = map[string]any{
+	"results": []any{map[string]any{
+		"url":    "https://one.example/",
+		"extras": map[string]any{"ranks": map[int]string{1: "first"}},
+	}},
+}
+
+func TestTOONFailureExplainsError(t *testing.T) {
+	var buf bytes.Buffer
+	err := runCommand(t, []string{"--output", "toon", "search", "q"}, func(cmd *cli.Command) error {
+		return renderOutput(&buf, cmd, nestedMapOutput)
+	})
+	if err == nil {
+		t.Fatalf("got nil error, want a TOON encoding error; output:\n%s", buf.String())
+	}
+	for _, want := range []string{"failed to encode output as TOON", "unsupported map key type int", "--output json", "--toon-fallback"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %q", err, want)
+		}
+	}
+}
+
+func TestTOONFallbackWritesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	var err error
+	stderr := captureStderr(t, func() {
+		err = runCommand(t, []string{"--output", "toon", "--toon-fallback", "--no-meta", "search", "q"}, func(cmd *cli.Command) error {
+			return renderOutput(&buf, cmd, nestedMapOutput)
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("fallback output isn't JSON: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), `"first"`) {
+		t.Errorf("fallback output is missing the nested map:\n%s", buf.String())
+	}
+	if !strings.Contains(stderr, "writing JSON instead") {
+		t.Errorf("no fallback warning on stderr: %q", stderr)
+	}
+}
+
+func TestEchoRequest(t *testing.T) {
+	srv := echoSearchServer(t)
+	for _, args := range [][]string{
+		{"search", "--set", "userLocation=NZ", "rust"},
+		{"answer", "rust"},
+	} {
+		stdout, stderr, err := runCLI(t, srv.URL, append([]string{"--output", "json", "--echo-request"}, args...)...)
+		if err != nil {
+			t.Fatalf("%v: %v\nstderr: %s", args, err, stderr)
+		}
+		var out struct {
+			Request map[string]any `json:"request"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+			t.Fatalf("%v: output isn't JSON: %v\n%s", args, err, stdout)
+		}
+		if out.Request["query"] != "rust" {
+			t.Errorf("%v: got request %v, want the query sent", args, out.Request)
+		}
+		if args[0] == "search" && out.Request["userLocation"] != "NZ" {
+			t.Errorf("%v: got request %v, want the --set field merged in", args, out.Request)
+		}
+	}
+}
+
+func TestMetadataTitleFallback(t *testing.T) {
+	resp := &client.SearchResponse{Results: []client.SearchResult{{
+		URL:      "https://one.example/",
+		Metadata: &client.PageMetadata{Title: "Page Title From Metadata"},
+	}}}
+	for _, format := range []string{"table", "csv", "markdown"} {
+		var buf bytes.Buffer
+		err := runCommand(t, []string{"--no-pager", "--output", format, "search", "q"}, func(cmd *cli.Command) error {
+			return renderOutput(&buf, cmd, resp)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "Page Title From Metadata") {
+			t.Errorf("--output %s doesn't fall back to the metadata title:\n%s", format, buf.String())
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+const defaultPager = "less -R"
+
+// usePager reports whether output should be paged: only for human-readable
+// formats on a terminal, and never in quiet mode, with --jq, --template-file or
+// --no-pager.
+func usePager(cmd *cli.Command) bool {
+	if cmd.Root().Bool("no-pager") || isQuietMode(cmd) || cmd.Root().String("jq") != "" || cmd.Root().String("template-file") != "" || !isTerminal() {
+		return false
+	}
+	format := getOutputFormat(cmd)
+	return format == "" || format == "table" || format == "report" || format == "markdown"
+}
+
+// writePaged writes out to stdout, piping it through $PAGER when it is taller
+// than the terminal. Falls back to writing directly if the pager can't start.
+func writePaged(out []byte) error {
+	_, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || bytes.Count(out, []byte("\n")) < height {
+		_, err := os.Stdout.Write(out)
+		return err
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = defaultPager
+	}
+	args := strings.Fields(pager)
+	if len(args) == 0 || args[0] == "cat" {
+		_, err := os.Stdout.Write(out)
+		return err
+	}
+
+	p := exec.Command(args[0], args[1:]...)
+	p.Stdin = bytes.NewReader(out)
+	p.Stdout = os.Stdout
+	p.Stderr = os.Stderr
+	if os.Getenv("LESS") == "" {
+		// Keep colors, and don't clear the screen on exit
+		p.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := p.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("pager %q failed: %w", pager, err)
+		}
+		_, err := os.Stdout.Write(out)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/12458/exa-cli/internal/client"
+)
+
+// maxEmptyPages is how many pages in a row may bring no new results before
+// searchPaged gives up. Such a page is full of URLs already seen, which the
+// API can return despite the excluded domains, so the search may still have
+// more to find.
+const maxEmptyPages = 2
+
+// searchCalls returns the number of /search requests searchPaged makes for
+// numResults results when every page brings new results
+func searchCalls(numResults int) int {
+	return max(1, (numResults+client.MaxSearchResults-1)/client.MaxSearchResults)
+}
+
+// searchPaged runs req, paginating when it asks for more than
+// client.MaxSearchResults results. The API has no cursor, so each later page
+// excludes the domains of the results so far; results are deduplicated by URL
+// and the pages are merged into a single response. Paging stops early at an
+// empty page, at a short page (fewer results than asked for, so the last
+// one), or after maxEmptyPages full pages in a row that add nothing new.
+// Searches limited to --include-domains can't be paged this way and are
+// capped at one page with a warning.
+//
+// If checkpoint is set, the progress is saved to that file after each page,
+// and a search interrupted by an error resumes from it when run again. The
+// file is removed once the search completes.
+func searchPaged(ctx context.Context, c *client.Client, req *client.SearchRequest, checkpoint string) (*client.SearchResponse, error) {
+	total := req.NumResults
+	if total <= client.MaxSearchResults {
+		return c.Search(ctx, req)
+	}
+
+	page := *req
+	page.NumResults = client.MaxSearchResults
+	if len(req.IncludeDomains) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: searches limited to --include-domains can't be paginated, so only the first %d of %d results are returned\n", client.MaxSearchResults, total)
+		return c.Search(ctx, &page)
+	}
+	body, err := checkpointBody(req)
+	if err != nil {
+		return nil, err
+	}
+	state := &searchCheckpoint{
+		Request:        body,
+		Response:       &client.SearchResponse{},
+		ExcludeDomains: slices.Clone(req.ExcludeDomains),
+	}
+	if checkpoint != "" {
+		resumed, err := loadCheckpoint(checkpoint, body)
+		if err != nil {
+			return nil, err
+		}
+		if resumed != nil {
+			state = resumed
+			fmt.Fprintf(os.Stderr, "Resuming from %s with %d of %d results\n", checkpoint, len(state.Response.Results), total)
+		}
+	}
+
+	merged := state.Response
+	seen := make(map[string]bool, len(merged.Results))
+	for _, r := range merged.Results {
+		seen[r.URL] = true
+	}
+	for len(merged.Results) < total {
+		page.ExcludeDomains = state.ExcludeDomains
+		page.NumResults = min(client.MaxSearchResults, total-len(merged.Results))
+		resp, err := c.Search(ctx, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		if state.Pages == 0 {
+			merged.RequestID = resp.RequestID
+			merged.AutopromptString = resp.AutopromptString
+			merged.ResolvedSearchType = resp.ResolvedSearchType
+		}
+		state.Pages++
+		if resp.CostDollars != nil {
+			if merged.CostDollars == nil {
+				merged.CostDollars = &client.CostDollars{}
+			}
+			merged.CostDollars.Total += resp.CostDollars.Total
+		}
+
+		added := 0
+		for _, r := range resp.Results {
+			if seen[r.URL] {
+				continue
+			}
+			seen[r.URL] = true
+			merged.Results = append(merged.Results, r)
+			added++
+			if domain := resultDomain(r.URL); domain != "" && !slices.Contains(state.ExcludeDomains, domain) {
+				state.ExcludeDomains = append(state.ExcludeDomains, domain)
+			}
+		}
+		if added > 0 {
+			state.EmptyPages = 0
+		} else {
+			state.EmptyPages++
+		}
+
+		if checkpoint != "" {
+			if err := saveCheckpoint(checkpoint, state); err != nil {
+				return nil, err
+			}
+		}
+		if len(resp.Results) < page.NumResults || state.EmptyPages == maxEmptyPages {
+			break
+		}
+	}
+
+	if checkpoint != "" {
+		if err := os.Remove(checkpoint); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove checkpoint: %w", err)
+		}
+	}
+	if len(merged.Results) < total {
+		fmt.Fprintf(os.Stderr, "warning: found %d of the %d results requested\n", len(merged.Results), total)
+	}
+	return merged, nil
+}
+
+// searchCheckpoint is the progress of a paginated search, saved by
+// --checkpoint after each page so an interrupted search can resume
+type searchCheckpoint struct {
+	// Request is the body sent for the search being paginated, extra
+	// fields included, to check that a resumed run is for the same one
+	Request json.RawMessage `json:"request"`
+	// Response holds the merged results and metadata so far
+	Response       *client.SearchResponse `json:"response"`
+	ExcludeDomains []string               `json:"excludeDomains"`
+	Pages          int                    `json:"pages"`
+	EmptyPages     int                    `json:"emptyPages"`
+}
+
+// checkpointBody returns the JSON body sent for req, as saved in its
+// checkpoint
+func checkpointBody(req *client.SearchRequest) (json.RawMessage, error) {
+	body, err := req.Body()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(body)
+}
+
+// loadCheckpoint reads the checkpoint at path for the search sending body. It
+// returns nil if there is none, and an error if it was saved for a different
+// search.
+func loadCheckpoint(path string, body json.RawMessage) (*searchCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var cp searchCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil || cp.Request == nil || cp.Response == nil {
+		return nil, fmt.Errorf("%s isn't a search checkpoint (delete it to start over)", path)
+	}
+	if !bytes.Equal(cp.Request, body) {
+		return nil, fmt.Errorf("checkpoint %s was saved for a different search (delete it to start over)", path)
+	}
+	return &cp, nil
+}
+
+// saveCheckpoint writes cp to path atomically, through a temporary file in
+// the same directory, so an interrupted write never leaves a partial
+// checkpoint
+func saveCheckpoint(path string, cp *searchCheckpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
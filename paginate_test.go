@@ -0,0 +1,422 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/12458/exa-cli/internal/client"
+)
+
+// searchPages is a mock /search endpoint that returns one canned page per
+// request and records the requests it received
+type searchPages struct {
+	pages [][]client.SearchResult
+
+	mu       sync.Mutex
+	requests []client.SearchRequest
+}
+
+func (p *searchPages) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req client.SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	var results []client.SearchResult
+	if n := len(p.requests); n <= len(p.pages) {
+		results = p.pages[n-1]
+	}
+	p.mu.Unlock()
+
+	results = results[:min(len(results), req.NumResults)]
+	_ = json.NewEncoder(w).Encode(client.SearchResponse{Results: results, CostDollars: &client.CostDollars{Total: 0.01}})
+}
+
+// pageResults returns n results with URLs https://<prefix><i/perDomain>.com/<i>,
+// so each domain has perDomain results
+func pageResults(prefix string, from, n, perDomain int) []client.SearchResult {
+	results := make([]client.SearchResult, n)
+	for i := range results {
+		id := from + i
+		u := fmt.Sprintf("https://%s%d.com/%d", prefix, id/perDomain, id)
+		results[i] = client.SearchResult{Title: u, URL: u, ID: u}
+	}
+	return results
+}
+
+// newPagesClient starts a mock search server for pages and returns a client
+// for it
+func newPagesClient(t *testing.T, pages *searchPages) *client.Client {
+	t.Helper()
+	srv := httptest.NewServer(pages)
+	t.Cleanup(srv.Close)
+	c, err := client.New("test-key", client.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSearchPagedMergesOverlappingPages(t *testing.T) {
+	// Page two repeats five URLs from page one before 40 new ones, and comes
+	// up short of the 50 asked for, which ends the search
+	page1 := pageResults("a", 0, 100, 2)
+	page2 := append(slices.Clone(page1[:5]), pageResults("b", 0, 40, 2)...)
+	pages := &searchPages{pages: [][]client.SearchResult{page1, page2}}
+	c := newPagesClient(t, pages)
+
+	var resp *client.SearchResponse
+	stderr := captureStderr(t, func() {
+		var err error
+		resp, err = searchPaged(context.Background(), c, &client.SearchRequest{
+			Query:          "q",
+			NumResults:     150,
+			ExcludeDomains: []string{"excluded.com"},
+		}, "")
+		if err != nil {
+			t.Errorf("searchPaged: %v", err)
+		}
+	})
+	if resp == nil {
+		t.FailNow()
+	}
+
+	if len(pages.requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(pages.requests))
+	}
+	if got := pages.requests[0].NumResults; got != client.MaxSearchResults {
+		t.Errorf("page 1 asked for %d results, want %d", got, client.MaxSearchResults)
+	}
+	if got := pages.requests[1].NumResults; got != 50 {
+		t.Errorf("page 2 asked for %d results, want 50", got)
+	}
+
+	// Page two excludes the caller's domains and every domain on page one
+	excluded := pages.requests[1].ExcludeDomains
+	if len(excluded) != 51 || excluded[0] != "excluded.com" {
+		t.Errorf("page 2 excluded %d domains starting with %v, want excluded.com and the 50 page 1 domains", len(excluded), excluded[:min(len(excluded), 3)])
+	}
+	for _, r := range page1 {
+		if domain := resultDomain(r.URL); !slices.Contains(excluded, domain) {
+			t.Errorf("page 2 didn't exclude page 1 domain %s", domain)
+			break
+		}
+	}
+	if got := pages.requests[0].ExcludeDomains; len(got) != 1 {
+		t.Errorf("page 1 excluded %v, want only the caller's domains", got)
+	}
+
+	if len(resp.Results) != 140 {
+		t.Errorf("got %d results, want 140 (100 + 40 new)", len(resp.Results))
+	}
+	seen := make(map[string]bool)
+	for _, r := range resp.Results {
+		if seen[r.URL] {
+			t.Errorf("duplicate result %s", r.URL)
+		}
+		seen[r.URL] = true
+	}
+	if resp.CostDollars == nil || resp.CostDollars.Total != 0.02 {
+		t.Errorf("got cost %v, want the two pages' costs added up", resp.CostDollars)
+	}
+	if !strings.Contains(stderr, "found 140 of the 150 results requested") {
+		t.Errorf("missing shortfall warning, stderr: %q", stderr)
+	}
+}
+
+func TestSearchPagedStopsWhenTotalReached(t *testing.T) {
+	pages := &searchPages{pages: [][]client.SearchResult{pageResults("a", 0, 100, 1), pageResults("b", 0, 100, 1)}}
+	c := newPagesClient(t, pages)
+
+	var resp *client.SearchResponse
+	stderr := captureStderr(t, func() {
+		resp, _ = searchPaged(context.Background(), c, &client.SearchRequest{Query: "q", NumResults: 120}, "")
+	})
+	if resp == nil || len(resp.Results) != 120 {
+		t.Fatalf("got %v results, want 120", resp)
+	}
+	if len(pages.requests) != 2 || pages.requests[1].NumResults != 20 {
+		t.Errorf("got requests %+v, want a second page of 20", pages.requests)
+	}
+	if stderr != "" {
+		t.Errorf("unexpected warning: %q", stderr)
+	}
+}
+
+func TestSearchPagedSinglePage(t *testing.T) {
+	pages := &searchPages{pages: [][]client.SearchResult{pageResults("a", 0, 100, 1)}}
+	c := newPagesClient(t, pages)
+
+	resp, err := searchPaged(context.Background(), c, &client.SearchRequest{Query: "q", NumResults: 100}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 100 || len(pages.requests) != 1 || pages.requests[0].ExcludeDomains != nil {
+		t.Errorf("got %d results from %d requests, want 100 from a single plain request", len(resp.Results), len(pages.requests))
+	}
+}
+
+func TestSearchPagedIncludeDomainsWarns(t *testing.T) {
+	pages := &searchPages{pages: [][]client.SearchResult{pageResults("a", 0, 100, 10)}}
+	c := newPagesClient(t, pages)
+
+	var resp *client.SearchResponse
+	stderr := captureStderr(t, func() {
+		resp, _ = searchPaged(context.Background(), c, &client.SearchRequest{
+			Query:          "q",
+			NumResults:     250,
+			IncludeDomains: []string{"a0.com"},
+		}, "")
+	})
+	if resp == nil || len(resp.Results) != 100 {
+		t.Fatalf("got %v, want the first 100 results", resp)
+	}
+	if len(pages.requests) != 1 || pages.requests[0].NumResults != client.MaxSearchResults {
+		t.Errorf("got requests %+v, want one request for %d results", pages.requests, client.MaxSearchResults)
+	}
+	if !strings.Contains(stderr, "--include-domains can't be paginated, so only the first 100 of 250 results are returned") {
+		t.Errorf("missing --include-domains warning, stderr: %q", stderr)
+	}
+}
+
+func TestSearchPagedStopsAtEmptyPage(t *testing.T) {
+	pages := &searchPages{pages: [][]client.SearchResult{pageResults("a", 0, 100, 1), {}}}
+	c := newPagesClient(t, pages)
+
+	var resp *client.SearchResponse
+	stderr := captureStderr(t, func() {
+		resp, _ = searchPaged(context.Background(), c, &client.SearchRequest{Query: "q", NumResults: 250}, "")
+	})
+	if resp == nil || len(resp.Results) != 100 {
+		t.Fatalf("got %v, want the first page's 100 results", resp)
+	}
+	if len(pages.requests) != 2 {
+		t.Errorf("got %d requests, want 2", len(pages.requests))
+	}
+	if !strings.Contains(stderr, "found 100 of the 250 results requested") {
+		t.Errorf("missing shortfall warning, stderr: %q", stderr)
+	}
+}
+
+func TestSearchPagedStopsAtShortPage(t *testing.T) {
+	pages := &searchPages{pages: [][]client.SearchResult{
+		pageResults("a", 0, 100, 1),
+		pageResults("b", 0, 30, 1),
+		pageResults("c", 0, 100, 1),
+	}}
+	c := newPagesClient(t, pages)
+
+	var resp *client.SearchResponse
+	captureStderr(t, func() {
+		resp, _ = searchPaged(context.Background(), c, &client.SearchRequest{Query: "q", NumResults: 250}, "")
+	})
+	if resp == nil || len(resp.Results) != 130 {
+		t.Fatalf("got %v, want 130 results", resp)
+	}
+	if len(pages.requests) != 2 {
+		t.Errorf("got %d requests, want 2: a short page is the last", len(pages.requests))
+	}
+}
+
+func TestSearchPagedContinuesPastPageWithNothingNew(t *testing.T) {
+	// Page two is full but repeats page one, as if the API ignored the
+	// excluded domains; page three has new results again
+	page1 := pageResults("a", 0, 100, 1)
+	pages := &searchPages{pages: [][]client.SearchResult{page1, page1, pageResults("b", 0, 100, 1)}}
+	c := newPagesClient(t, pages)
+
+	var resp *client.SearchResponse
+	stderr := captureStderr(t, func() {
+		resp, _ = searchPaged(context.Background(), c, &client.SearchRequest{Query: "q", NumResults: 200}, "")
+	})
+	if resp == nil || len(resp.Results) != 200 {
+		t.Fatalf("got %v, want 200 results", resp)
+	}
+	if len(pages.requests) != 3 {
+		t.Errorf("got %d requests, want 3", len(pages.requests))
+	}
+	if stderr != "" {
+		t.Errorf("unexpected warning: %q", stderr)
+	}
+}
+
+func TestSearchPagedGivesUpAfterMaxEmptyPages(t *testing.T) {
+	page1 := pageResults("a", 0, 100, 1)
+	pages := &searchPages{pages: [][]client.SearchResult{page1, page1, page1, pageResults("b", 0, 100, 1)}}
+	c := newPagesClient(t, pages)
+
+	var resp *client.SearchResponse
+	captureStderr(t, func() {
+		resp, _ = searchPaged(context.Background(), c, &client.SearchRequest{Query: "q", NumResults: 300}, "")
+	})
+	if resp == nil || len(resp.Results) != 100 {
+		t.Fatalf("got %v, want the first page's 100 results", resp)
+	}
+	if want := 1 + maxEmptyPages; len(pages.requests) != want {
+		t.Errorf("got %d requests, want %d", len(pages.requests), want)
+	}
+}
+
+// failingPages wraps a searchPages mock, failing every request after the
+// first n with a 500
+type failingPages struct {
+	*searchPages
+	n int
+}
+
+func (f *failingPages) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	fail := len(f.requests) >= f.n
+	f.mu.Unlock()
+	if fail {
+		http.Error(w, `{"error":"boom"}`, http.StatusInternalServerError)
+		return
+	}
+	f.searchPages.ServeHTTP(w, r)
+}
+
+func TestSearchPagedCheckpointResumes(t *testing.T) {
+	checkpoint := filepath.Join(t.TempDir(), "search.checkpoint")
+	req := &client.SearchRequest{Query: "q", NumResults: 300}
+	page1, page2, page3 := pageResults("a", 0, 100, 1), pageResults("b", 0, 100, 1), pageResults("c", 0, 100, 1)
+
+	// The first run gets one page before the API starts failing
+	failing := &failingPages{searchPages: &searchPages{pages: [][]client.SearchResult{page1}}, n: 1}
+	srv := httptest.NewServer(failing)
+	t.Cleanup(srv.Close)
+	c, err := client.New("test-key", client.WithBaseURL(srv.URL), client.WithRetries(0, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := searchPaged(context.Background(), c, req, checkpoint); err == nil {
+		t.Fatal("got nil error from the failing run")
+	}
+	if _, err := os.Stat(checkpoint); err != nil {
+		t.Fatalf("no checkpoint after the failed run: %v", err)
+	}
+
+	// The second run picks up at page two, excluding page one's domains
+	pages := &searchPages{pages: [][]client.SearchResult{page2, page3}}
+	var resp *client.SearchResponse
+	stderr := captureStderr(t, func() {
+		resp, err = searchPaged(context.Background(), newPagesClient(t, pages), req, checkpoint)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr, "Resuming from "+checkpoint+" with 100 of 300 results") {
+		t.Errorf("missing resume message, stderr: %q", stderr)
+	}
+	if len(pages.requests) != 2 {
+		t.Errorf("resumed run made %d requests, want 2", len(pages.requests))
+	}
+	if excluded := pages.requests[0].ExcludeDomains; len(excluded) != 100 || excluded[0] != "a0.com" {
+		t.Errorf("resumed page excluded %d domains, want page one's 100", len(excluded))
+	}
+	if len(resp.Results) != 300 || resp.Results[0].URL != page1[0].URL || resp.Results[299].URL != page3[99].URL {
+		t.Errorf("got %d results, want all three pages in order", len(resp.Results))
+	}
+	if _, err := os.Stat(checkpoint); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("checkpoint not removed after the search completed: %v", err)
+	}
+}
+
+func TestSearchPagedCheckpointForDifferentSearch(t *testing.T) {
+	saved := &client.SearchRequest{Query: "q", NumResults: 300, ExtraFields: map[string]any{"userLocation": "NZ"}}
+	for _, tt := range []struct {
+		name string
+		req  *client.SearchRequest
+	}{
+		{"query", &client.SearchRequest{Query: "other", NumResults: 300, ExtraFields: saved.ExtraFields}},
+		{"extra fields", &client.SearchRequest{Query: "q", NumResults: 300, ExtraFields: map[string]any{"userLocation": "US"}}},
+		{"no extra fields", &client.SearchRequest{Query: "q", NumResults: 300}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			checkpoint := filepath.Join(t.TempDir(), "search.checkpoint")
+			body, err := checkpointBody(saved)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := saveCheckpoint(checkpoint, &searchCheckpoint{Request: body, Response: &client.SearchResponse{}}); err != nil {
+				t.Fatal(err)
+			}
+
+			pages := &searchPages{}
+			_, err = searchPaged(context.Background(), newPagesClient(t, pages), tt.req, checkpoint)
+			if err == nil || !strings.Contains(err.Error(), "different search") {
+				t.Errorf("got %v, want a different search error", err)
+			}
+			if len(pages.requests) != 0 {
+				t.Errorf("made %d requests, want none", len(pages.requests))
+			}
+		})
+	}
+}
+
+func TestSaveCheckpointLeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	checkpoint := filepath.Join(dir, "search.checkpoint")
+	for range 2 {
+		if err := saveCheckpoint(checkpoint, &searchCheckpoint{Request: json.RawMessage(`{}`), Response: &client.SearchResponse{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d files, want only the checkpoint", len(entries))
+	}
+}
+
+func TestSearchNumResultsAboveCap(t *testing.T) {
+	pages := &searchPages{pages: [][]client.SearchResult{
+		pageResults("a", 0, 100, 1),
+		pageResults("b", 0, 100, 1),
+		pageResults("c", 0, 100, 1),
+	}}
+	srv := httptest.NewServer(pages)
+	t.Cleanup(srv.Close)
+
+	stdout, stderr, err := runCLI(t, srv.URL, "--output", "jsonl", "search", "-n", "250", "rust")
+	if err != nil {
+		t.Fatalf("search -n 250: %v\nstderr: %s", err, stderr)
+	}
+	if n := strings.Count(stdout, "\n"); n != 250 {
+		t.Errorf("got %d results, want 250", n)
+	}
+	var asked []int
+	for _, req := range pages.requests {
+		asked = append(asked, req.NumResults)
+	}
+	if !slices.Equal(asked, []int{100, 100, 50}) {
+		t.Errorf("pages asked for %v results, want [100 100 50]", asked)
+	}
+	if stderr != "" {
+		t.Errorf("unexpected stderr: %q", stderr)
+	}
+}
+
+func TestSearchCalls(t *testing.T) {
+	tests := []struct{ n, want int }{{0, 1}, {1, 1}, {100, 1}, {101, 2}, {250, 3}, {1000, 10}}
+	for _, tt := range tests {
+		if got := searchCalls(tt.n); got != tt.want {
+			t.Errorf("searchCalls(%d) = %d, want %d", tt.n, got, tt.want)
+		}
+	}
+}
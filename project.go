@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/toon-format/toon-go"
+	"github.com/urfave/cli/v3"
+)
+
+// resultFields returns the JSON names of the result fields accepted by
+// --project, in declaration order
+func resultFields() []string {
+	t := reflect.TypeFor[client.SearchResult]()
+	fields := make([]string, 0, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// projectFields returns the fields named by --project, or nil if it is unset.
+// Unknown names are an error listing the valid fields.
+func projectFields(cmd *cli.Command) ([]string, error) {
+	fields := cmd.Root().StringSlice("project")
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	valid := resultFields()
+	for i, f := range fields {
+		fields[i] = strings.TrimSpace(f)
+		if !slices.Contains(valid, fields[i]) {
+			return nil, fmt.Errorf("invalid --project field %q (valid: %s)", fields[i], strings.Join(valid, ", "))
+		}
+	}
+	return fields, nil
+}
+
+// projectResult reduces r to the named fields. Fields that are empty in r are
+// left out, as in unprojected output.
+func projectResult(r client.SearchResult, fields []string) (map[string]any, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]any
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	projected := make(map[string]any, len(fields))
+	for _, f := range fields {
+		if v, ok := all[f]; ok {
+			projected[f] = v
+		}
+	}
+	return projected, nil
+}
+
+// projectResults applies projectResult to each result
+func projectResults(results []client.SearchResult, fields []string) ([]map[string]any, error) {
+	projected := make([]map[string]any, 0, len(results))
+	for _, r := range results {
+		p, err := projectResult(r, fields)
+		if err != nil {
+			return nil, err
+		}
+		projected = append(projected, p)
+	}
+	return projected, nil
+}
+
+// projectContentsResults applies projectResult to each contents result,
+// keeping its fetch status
+func projectContentsResults(results []contentsResult, fields []string) ([]map[string]any, error) {
+	projected := make([]map[string]any, 0, len(results))
+	for _, r := range results {
+		p, err := projectResult(r.SearchResult, fields)
+		if err != nil {
+			return nil, err
+		}
+		if r.Status != "" {
+			p["status"] = r.Status
+		}
+		if r.Error != nil {
+			p["error"] = r.Error
+		}
+		projected = append(projected, p)
+	}
+	return projected, nil
+}
+
+// projectOutput applies --project to the results of a JSON envelope or a
+// search/contents response, and to each query's output in a search --stdin
+// batch. Responses are converted to a map so their results can be replaced;
+// other values are returned unchanged.
+func projectOutput(cmd *cli.Command, v any) (any, error) {
+	// Batches are keyed by query, whether or not fields are projected
+	if batch, ok := v.(*searchBatch); ok {
+		out := make(searchBatchOutput, len(batch.Queries))
+		for i, q := range batch.Queries {
+			value, err := projectOutput(cmd, q.Response)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = toon.Field{Key: q.Query, Value: value}
+		}
+		return out, nil
+	}
+
+	fields, err := projectFields(cmd)
+	if err != nil || fields == nil {
+		return v, err
+	}
+
+	var results []client.SearchResult
+	switch resp := v.(type) {
+	case jsonEnvelope:
+		switch results := resp.Results.(type) {
+		case []client.SearchResult:
+			if resp.Results, err = projectResults(results, fields); err != nil {
+				return nil, err
+			}
+		case []contentsResult:
+			if resp.Results, err = projectContentsResults(results, fields); err != nil {
+				return nil, err
+			}
+		}
+		return resp, nil
+	case searchBatchOutput:
+		for i, f := range resp {
+			if resp[i].Value, err = projectOutput(cmd, f.Value); err != nil {
+				return nil, err
+			}
+		}
+		return resp, nil
+	case *client.SearchResponse:
+		results = resp.Results
+	case *client.ContentsResponse:
+		results = resp.Results
+	default:
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	if out["results"], err = projectResults(results, fields); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/12458/exa-cli/internal/client"
+)
+
+// maxRelatedQueries is the number of follow-up searches shown by --show-related
+const maxRelatedQueries = 5
+
+// relatedStopwords are common words never suggested as related terms
+var relatedStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "how": true, "in": true,
+	"is": true, "it": true, "its": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "this": true, "to": true, "vs": true,
+	"what": true, "why": true, "with": true, "your": true, "you": true,
+}
+
+// relatedQueries suggests follow-up searches by appending to query the terms
+// that appear in the most result titles, ignoring stopwords, short words and
+// words already in the query. Terms must appear in at least two titles.
+func relatedQueries(query string, results []client.SearchResult) []string {
+	inQuery := make(map[string]bool)
+	for _, w := range titleWords(query) {
+		inQuery[w] = true
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	for _, r := range results {
+		seen := make(map[string]bool)
+		for _, w := range titleWords(resultTitle(r)) {
+			if seen[w] || inQuery[w] || relatedStopwords[w] || len([]rune(w)) < 3 {
+				continue
+			}
+			seen[w] = true
+			if counts[w] == 0 {
+				order = append(order, w)
+			}
+			counts[w]++
+		}
+	}
+
+	// Most frequent first; ties keep first-seen order
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]] > counts[order[j]]
+	})
+
+	var related []string
+	for _, w := range order {
+		if counts[w] < 2 || len(related) == maxRelatedQueries {
+			break
+		}
+		related = append(related, query+" "+w)
+	}
+	return related
+}
+
+// titleWords splits s into lowercase words of letters and digits
+func titleWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// printRelated prints suggested follow-up searches below the results table
+func printRelated(w io.Writer, related []string) {
+	if len(related) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Related searches:")
+	for _, q := range related {
+		fmt.Fprintf(w, "  exa search %q\n", q)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/urfave/cli/v3"
+)
+
+// maxResearchDepth caps --depth so the combined sources fit in one contents request
+const maxResearchDepth = client.MaxContentsIDs / 2
+
+// researchReport is the result of the research command: the search results
+// for the topic and pages similar to the top result, each with a summary
+type researchReport struct {
+	Topic   string                `json:"topic" toon:"topic"`
+	Sources []client.SearchResult `json:"sources" toon:"sources"`
+	Related []client.SearchResult `json:"related" toon:"related"`
+}
+
+func researchCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "research",
+		Usage:     "Research a topic: search, find pages similar to the top result, and summarize them all",
+		ArgsUsage: "<topic>",
+		UsageText: `Examples:
+  exa research "solid-state batteries"
+  exa research --depth 10 "vector database benchmarks" > report.md`,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "depth",
+				Usage: fmt.Sprintf("Number of search results and similar pages to gather (1-%d)", maxResearchDepth),
+				Value: 5,
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() == 0 {
+				return fmt.Errorf("topic is required")
+			}
+			topic := cmd.Args().First()
+			if strings.TrimSpace(topic) == "" {
+				return fmt.Errorf("topic is empty")
+			}
+			depth := int(cmd.Int("depth"))
+			if depth < 1 || depth > maxResearchDepth {
+				return fmt.Errorf("depth must be between 1 and %d", maxResearchDepth)
+			}
+			if err := validateOutputFlags(cmd); err != nil {
+				return err
+			}
+
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			defer warnLowQuota(c)
+
+			ctx, cancel := withTimeout(ctx, cmd)
+			defer cancel()
+			failed := newFailures(cmd)
+			report, err := research(ctx, c, topic, depth, failed)
+			if err != nil {
+				return timeoutErr(ctx, err)
+			}
+			return errors.Join(printOutput(cmd, report), failed.err())
+		},
+	}
+}
+
+// research runs a search for topic, then concurrently finds pages similar to
+// the top result and summarizes the search results. Similar pages not already
+// among the search results are summarized last.
+//
+// The search itself must succeed. Failures of the later calls are added to
+// failed; when the policy lets the command carry on, the report just lacks
+// the related pages or summaries that couldn't be fetched.
+func research(ctx context.Context, c *client.Client, topic string, depth int, failed *failures) (*researchReport, error) {
+	search, err := c.Search(ctx, &client.SearchRequest{Query: topic, NumResults: depth})
+	if err != nil {
+		return nil, err
+	}
+	report := &researchReport{Topic: topic, Sources: search.Results}
+	if len(search.Results) == 0 {
+		return report, nil
+	}
+
+	var (
+		wg                 sync.WaitGroup
+		similar            *client.SearchResponse
+		summaries          *client.ContentsResponse
+		similarErr, sumErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		similar, similarErr = c.FindSimilar(ctx, &client.FindSimilarRequest{URL: search.Results[0].URL, NumResults: depth})
+	}()
+	go func() {
+		defer wg.Done()
+		summaries, sumErr = summarize(ctx, c, search.Results)
+	}()
+	wg.Wait()
+	if similarErr != nil {
+		if err := failed.add("find similar", similarErr); err != nil {
+			return nil, err
+		}
+		similar = &client.SearchResponse{}
+	}
+	if sumErr != nil {
+		if err := failed.add("summarize sources", sumErr); err != nil {
+			return nil, err
+		}
+		summaries = &client.ContentsResponse{}
+	}
+
+	// Related pages are the similar ones not already found by the search
+	seen := make(map[string]bool, len(search.Results))
+	for _, r := range search.Results {
+		seen[r.URL] = true
+	}
+	for _, r := range similar.Results {
+		if !seen[r.URL] {
+			seen[r.URL] = true
+			report.Related = append(report.Related, r)
+		}
+	}
+	if len(report.Related) > 0 {
+		related, err := summarize(ctx, c, report.Related)
+		if err != nil {
+			if err := failed.add("summarize related pages", err); err != nil {
+				return nil, err
+			}
+		} else {
+			summaries.Results = append(summaries.Results, related.Results...)
+		}
+	}
+
+	bySummary := make(map[string]string, len(summaries.Results))
+	for _, r := range summaries.Results {
+		bySummary[r.ID] = r.Summary
+		bySummary[r.URL] = r.Summary
+	}
+	for i := range report.Sources {
+		report.Sources[i].Summary = bySummary[report.Sources[i].URL]
+	}
+	for i := range report.Related {
+		report.Related[i].Summary = bySummary[report.Related[i].URL]
+	}
+	return report, nil
+}
+
+// summarize fetches a summary for each result's URL
+func summarize(ctx context.Context, c *client.Client, results []client.SearchResult) (*client.ContentsResponse, error) {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.URL
+	}
+	return c.GetContents(ctx, &client.ContentsRequest{IDs: ids, Summary: true})
+}
+
+// printResearchMarkdown writes the report as a markdown document with
+// Overview, Sources and Related sections
+func printResearchMarkdown(w io.Writer, report *researchReport) {
+	fmt.Fprintf(w, "# %s\n", report.Topic)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "## Overview")
+	fmt.Fprintln(w)
+	for _, r := range report.Sources {
+		if r.Summary != "" {
+			fmt.Fprintf(w, "- **%s**: %s\n", resultHeading(r), r.Summary)
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "## Sources")
+	fmt.Fprintln(w)
+	for i, r := range report.Sources {
+		fmt.Fprintf(w, "%d. [%s](%s)", i+1, resultHeading(r), r.URL)
+		if r.PublishedDate != "" {
+			fmt.Fprintf(w, " (%s)", r.PublishedDate)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(report.Related) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "## Related")
+		fmt.Fprintln(w)
+		for _, r := range report.Related {
+			fmt.Fprintf(w, "- [%s](%s)", resultHeading(r), r.URL)
+			if r.Summary != "" {
+				fmt.Fprintf(w, ": %s", r.Summary)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/12458/exa-cli/internal/client"
+)
+
+// apiLatency is how long the mock API in BenchmarkSearchFull takes to answer
+const apiLatency = 5 * time.Millisecond
+
+// contentsServer is a mock API whose /search results carry text when
+// contents are requested inline, and whose /contents returns text for each
+// ID. It counts the requests sent to each endpoint.
+type contentsServer struct {
+	*httptest.Server
+	searches, contents atomic.Int64
+}
+
+func newContentsServer(tb testing.TB, results int) *contentsServer {
+	tb.Helper()
+	s := &contentsServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(apiLatency)
+		var resp client.SearchResponse
+		switch r.URL.Path {
+		case "/search":
+			s.searches.Add(1)
+			var req client.SearchRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			for i := range results {
+				result := client.SearchResult{URL: fmt.Sprintf("https://example.com/%d", i)}
+				if req.Contents != nil {
+					result.Text = "page text"
+				}
+				resp.Results = append(resp.Results, result)
+			}
+		case "/contents":
+			s.contents.Add(1)
+			var req client.ContentsRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			for _, id := range req.IDs {
+				resp.Results = append(resp.Results, client.SearchResult{URL: id, Text: "page text"})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// BenchmarkSearchFull compares search --text, which asks /search for the
+// contents inline, with a search followed by a contents request for its
+// results. The inline flow makes one round trip, so there's no second
+// request whose latency could overlap with rendering.
+func BenchmarkSearchFull(b *testing.B) {
+	const results = 5
+	urls := make([]string, results)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/%d", i)
+	}
+
+	b.Run("inline", func(b *testing.B) {
+		srv := newContentsServer(b, results)
+		for b.Loop() {
+			out, _, err := runCLI(b, srv.URL, "--output", "jsonl", "search", "--text", "-n", fmt.Sprint(results), "q")
+			if err != nil {
+				b.Fatal(err)
+			}
+			if strings.Count(out, "page text") != results {
+				b.Fatalf("want text for every result:\n%s", out)
+			}
+		}
+		if n := srv.contents.Load(); n != 0 {
+			b.Fatalf("search --text sent %d /contents requests, want 0", n)
+		}
+		b.ReportMetric(float64(srv.searches.Load()+srv.contents.Load())/float64(b.N), "requests/op")
+	})
+
+	b.Run("sequential", func(b *testing.B) {
+		srv := newContentsServer(b, results)
+		for b.Loop() {
+			if _, _, err := runCLI(b, srv.URL, "--output", "jsonl", "search", "-n", fmt.Sprint(results), "q"); err != nil {
+				b.Fatal(err)
+			}
+			out, _, err := runCLI(b, srv.URL, append([]string{"--output", "jsonl", "contents", "--text"}, urls...)...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if strings.Count(out, "page text") != results {
+				b.Fatalf("want text for every result:\n%s", out)
+			}
+		}
+		b.ReportMetric(float64(srv.searches.Load()+srv.contents.Load())/float64(b.N), "requests/op")
+	})
+}
+
+func TestSearchSavedResultsReadBeforeSearching(t *testing.T) {
+	srv := newContentsServer(t, 1)
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	for _, args := range [][]string{
+		{"search", "--merge", missing, "q"},
+		{"search", "--compare", missing, "q"},
+		{"search", "--merge", "-", "--compare", "-", "q"},
+	} {
+		withStdin(t, `{"results":[]}`)
+		if _, _, err := runCLI(t, srv.URL, args...); err == nil {
+			t.Errorf("%v: got nil error", args)
+		}
+	}
+	if n := srv.searches.Load(); n != 0 {
+		t.Errorf("sent %d searches, want none when the saved results can't be read", n)
+	}
+}
+
+func TestSearchQueryArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, "query is required"},
+		{[]string{""}, "query is empty"},
+		{[]string{"   "}, "query is empty"},
+		{[]string{"", "foo"}, "got 2 arguments"},
+		{[]string{"foo", "bar"}, "got 2 arguments"},
+		{[]string{"foo", "", "bar"}, "got 3 arguments"},
+		{[]string{"--", "", "foo"}, "got 2 arguments"},
+		{[]string{"-n", "3", "", "foo"}, "got 2 arguments"},
+	}
+	for _, tt := range tests {
+		_, _, err := runCLI(t, "http://exa.invalid", append([]string{"search"}, tt.args...)...)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("search %q: got %v, want an error containing %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestSearchBlankFlagValueIsNotAQuery(t *testing.T) {
+	srv := newContentsServer(t, 1)
+	if _, stderr, err := runCLI(t, srv.URL, "search", "--summary-query", "", "foo"); err != nil {
+		t.Fatalf("got %v, want the search to run\nstderr: %s", err, stderr)
+	}
+}
+
+func TestSearchMermaidLinks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req client.SearchRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		resp := client.SearchResponse{Results: []client.SearchResult{
+			{URL: "https://tokio.rs/"},
+			{URL: "https://docs.rs/tokio"},
+		}}
+		if req.Contents != nil && req.Contents.Extras != nil && req.Contents.Extras.Links > 0 {
+			resp.Results[0].Extras = &client.Extras{Links: []string{"https://docs.rs/tokio/latest"}}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	stdout, stderr, err := runCLI(t, srv.URL, "--output", "mermaid", "search", "--links", "5", "tokio")
+	if err != nil {
+		t.Fatalf("search --links: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "d1 -.-> d2") {
+		t.Errorf("no edge between the linked domains:\n%s", stdout)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/urfave/cli/v3"
+)
+
+func findSimilarCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "find-similar",
+		Aliases:   []string{"similar"},
+		Usage:     "Find pages similar to a URL",
+		ArgsUsage: "<url>",
+		UsageText: `Examples:
+  exa find-similar https://example.com/article
+  exa similar -n 20 -x example.com --summary https://example.com/article
+  exa similar --with-contents --concurrency 4 https://example.com/article`,
+		Flags: slices.Concat(
+			[]cli.Flag{
+				&cli.IntFlag{
+					Name:    "num-results",
+					Aliases: []string{"n"},
+					Usage:   "Number of results (1-100)",
+					Value:   10,
+				},
+			},
+			contentFlags(),
+			[]cli.Flag{
+				&cli.BoolFlag{
+					Name:  "with-contents",
+					Usage: "Fetch the similar pages' contents (full text unless --summary or --highlights are set) with separate contents requests",
+				},
+				&cli.IntFlag{
+					Name:  "batch-size",
+					Usage: fmt.Sprintf("With --with-contents, URLs per contents request (max %d)", client.MaxContentsIDs),
+				},
+				&cli.IntFlag{
+					Name:  "concurrency",
+					Usage: "With --with-contents, contents requests in flight at once; above 1 without --batch-size, one request per URL",
+					Value: 1,
+				},
+			},
+			domainFlags(),
+			publishedDateFlags(),
+			scoreFlags("similarity"),
+			extraFieldFlags(),
+		),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() == 0 {
+				return fmt.Errorf("URL is required")
+			}
+			target, err := normalizeURL(cmd.Args().First())
+			if err != nil {
+				return err
+			}
+			if err := resolveFileArgs(cmd); err != nil {
+				return err
+			}
+			if err := validateOutputFlags(cmd); err != nil {
+				return err
+			}
+
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			defer warnLowQuota(c)
+
+			req := &client.FindSimilarRequest{
+				URL:                target,
+				NumResults:         int(cmd.Int("num-results")),
+				IncludeDomains:     cmd.StringSlice("include-domains"),
+				ExcludeDomains:     cmd.StringSlice("exclude-domains"),
+				StartPublishedDate: cmd.String("start-published-date"),
+				EndPublishedDate:   cmd.String("end-published-date"),
+			}
+			if req.Contents, err = searchContentsOptions(cmd); err != nil {
+				return err
+			}
+			if req.ExtraFields, err = parseExtraFields(cmd); err != nil {
+				return err
+			}
+
+			// With --with-contents the contents come from their own requests
+			contents := req.Contents
+			calls := 1
+			batchSize, concurrency := 0, int(cmd.Int("concurrency"))
+			if cmd.Bool("with-contents") {
+				if batchSize, err = contentsBatchSize(cmd); err != nil {
+					return err
+				}
+				if concurrency < 1 {
+					return fmt.Errorf("concurrency must be at least 1")
+				}
+				if concurrency > 1 && batchSize == 0 {
+					batchSize = 1
+				}
+				if contents == nil {
+					contents = &client.ContentsOptions{Text: true}
+				}
+				req.Contents = nil
+				if batchSize > 0 {
+					calls += (req.NumResults + batchSize - 1) / batchSize
+				} else {
+					calls++
+				}
+			}
+
+			if contents != nil && !cmd.Root().Bool("yes") {
+				opts := contentOptions(contents.Text, contents.Summary, contents.Highlights)
+				if err := confirmExpensive(req.NumResults, opts, calls); err != nil {
+					return err
+				}
+			}
+
+			if err := echoRequest(cmd, req); err != nil {
+				return err
+			}
+			ctx, cancel := withTimeout(ctx, cmd)
+			defer cancel()
+			result, err := c.FindSimilar(ctx, req)
+			if err != nil {
+				return timeoutErr(ctx, err)
+			}
+			if !cmd.Bool("with-contents") || len(result.Results) == 0 {
+				return printOutput(cmd, result)
+			}
+
+			ids := make([]string, len(result.Results))
+			for i, r := range result.Results {
+				ids[i] = r.URL
+			}
+			contentsReq := &client.ContentsRequest{
+				IDs:        ids,
+				Text:       contents.Text,
+				Highlights: contents.Highlights,
+				Summary:    contents.Summary,
+				Metadata:   contents.Metadata,
+			}
+			failed := newFailures(cmd)
+			fetched, err := getContentsBatched(ctx, c, contentsReq, batchSize, concurrency, failed)
+			if err != nil {
+				return timeoutErr(ctx, err)
+			}
+			mergeContents(result.Results, fetched.Results)
+			warnFailedContents(fetched.Statuses)
+			if fetched.CostDollars != nil {
+				if result.CostDollars == nil {
+					result.CostDollars = &client.CostDollars{}
+				}
+				result.CostDollars.Total += fetched.CostDollars.Total
+			}
+			return errors.Join(printOutput(cmd, result), failed.err())
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/12458/exa-cli/internal/client"
+)
+
+func TestFindSimilarWithContents(t *testing.T) {
+	similar := []client.SearchResult{
+		{Title: "A", URL: "https://a.com/", ID: "https://a.com/", Score: 0.9},
+		{Title: "B", URL: "https://b.com/", ID: "https://b.com/", Score: 0.8},
+		{Title: "C", URL: "https://c.com/", ID: "https://c.com/", Score: 0.7},
+	}
+	var contentsReq client.ContentsRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc("/findSimilar", func(w http.ResponseWriter, r *http.Request) {
+		var req client.FindSimilarRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Contents != nil {
+			t.Errorf("findSimilar asked for contents %+v, want them fetched separately", req.Contents)
+		}
+		_ = json.NewEncoder(w).Encode(client.SearchResponse{Results: similar})
+	})
+	mux.HandleFunc("/contents", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&contentsReq)
+		// Contents come back in a different order, and B's fetch fails
+		_ = json.NewEncoder(w).Encode(client.ContentsResponse{
+			Results: []client.SearchResult{
+				{Title: "C page", URL: "https://c.com/", ID: "https://c.com/", Text: "text of C"},
+				{Title: "A page", URL: "https://a.com/", ID: "https://a.com/", Text: "text of A"},
+			},
+			Statuses: []client.ContentStatus{
+				{ID: "https://a.com/", Status: "success"},
+				{ID: "https://b.com/", Status: "error", Error: &client.ContentError{Tag: "CRAWL_NOT_FOUND", HTTPStatusCode: 404}},
+				{ID: "https://c.com/", Status: "success"},
+			},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	stdout, stderr, err := runCLI(t, srv.URL, "--output", "json", "--no-meta", "find-similar", "--with-contents", "https://seed.com/")
+	if err != nil {
+		t.Fatalf("find-similar: %v", err)
+	}
+
+	if got := strings.Join(contentsReq.IDs, " "); got != "https://a.com/ https://b.com/ https://c.com/" {
+		t.Errorf("contents requested for %q, want the similar URLs in order", got)
+	}
+
+	var out struct {
+		Results []client.SearchResult `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+		t.Fatalf("bad JSON output %q: %v", stdout, err)
+	}
+	want := []struct{ title, text string }{{"A", "text of A"}, {"B", ""}, {"C", "text of C"}}
+	if len(out.Results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(out.Results), len(want))
+	}
+	for i, w := range want {
+		// Results keep the similarity order and titles, with the contents
+		// merged in by URL
+		if r := out.Results[i]; r.Title != w.title || r.Text != w.text {
+			t.Errorf("result %d = %q with text %q, want %q with text %q", i, r.Title, r.Text, w.title, w.text)
+		}
+	}
+
+	if !strings.Contains(stderr, "failed to fetch contents for 1 URL(s): https://b.com/ (CRAWL_NOT_FOUND)") {
+		t.Errorf("missing warning for the failed URL, stderr: %q", stderr)
+	}
+}
+
+func TestSimilarWithContentsBatchSizeRange(t *testing.T) {
+	_, _, err := runCLI(t, "http://exa.invalid", "similar", "--with-contents", "--batch-size", "0", "https://example.com/")
+	if err == nil || !strings.Contains(err.Error(), "batch-size must be between 1 and 100") {
+		t.Errorf("got %v, want a range error", err)
+	}
+}
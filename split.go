@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/urfave/cli/v3"
+)
+
+// maxFileSlug caps the length of --split-output file names, before the
+// numeric suffix and extension
+const maxFileSlug = 80
+
+// fileSlug turns s into a lowercase file name of letters, digits and single
+// hyphens, dropping everything else. Returns "" if nothing is left.
+func fileSlug(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && b.Len() > 0 {
+			b.WriteRune('-')
+			hyphen = true
+		}
+	}
+	slug := []rune(strings.TrimSuffix(b.String(), "-"))
+	if len(slug) > maxFileSlug {
+		slug = []rune(strings.TrimSuffix(string(slug[:maxFileSlug]), "-"))
+	}
+	return string(slug)
+}
+
+// splitFileBase returns the file name for r without extension: its
+// slugified title, or host if the title is empty
+func splitFileBase(r client.SearchResult) string {
+	if base := fileSlug(resultTitle(r)); base != "" {
+		return base
+	}
+	if base := fileSlug(resultDomain(r.URL)); base != "" {
+		return base
+	}
+	return "page"
+}
+
+// createSplitFile creates base.md in dir, or base-2.md, base-3.md, ... when
+// that name is already taken, so existing files (from an earlier run, or
+// earlier results with the same title) are never overwritten
+func createSplitFile(dir, base string) (*os.File, error) {
+	for n := 1; ; n++ {
+		name := base + ".md"
+		if n > 1 {
+			name = fmt.Sprintf("%s-%d.md", base, n)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, err
+		}
+	}
+}
+
+// writeSplitOutput writes each contents result to its own markdown file with
+// frontmatter in dir, creating dir if needed, and returns the paths written
+func writeSplitOutput(cmd *cli.Command, dir string, resp *client.ContentsResponse) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	requested := requestedURLs(cmd)
+	paths := make([]string, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		var buf bytes.Buffer
+		printResultMarkdown(&buf, cmd, r, requested[r.ID], 0, nil)
+		f, err := createSplitFile(dir, splitFileBase(r))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create output file: %w", err)
+		}
+		_, err = f.Write(buf.Bytes())
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", f.Name(), err)
+		}
+		paths = append(paths, f.Name())
+	}
+	return paths, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/urfave/cli/v3"
+)
+
+func TestFileSlug(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Hello, World!", "hello-world"},
+		{"  --a/b\\c:d*e?  ", "a-b-c-d-e"},
+		{"Ünïcödé Tïtle", "ünïcödé-tïtle"},
+		{"../../etc/passwd", "etc-passwd"},
+		{"!!!", ""},
+		{strings.Repeat("ab ", 50), strings.TrimSuffix(strings.Repeat("ab-", 27), "-")},
+	}
+	for _, tt := range tests {
+		if got := fileSlug(tt.in); got != tt.want {
+			t.Errorf("fileSlug(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteSplitOutputKeepsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "pricing.md")
+	if err := os.WriteFile(existing, []byte("my notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := &client.ContentsResponse{Results: []client.SearchResult{
+		{ID: "https://a.example/", URL: "https://a.example/", Title: "Pricing", Text: "a"},
+		{ID: "https://b.example/", URL: "https://b.example/", Title: "Pricing", Text: "b"},
+		{ID: "https://c.example/", URL: "https://c.example/", Text: "c"},
+	}}
+	var paths []string
+	err := runCommand(t, []string{"contents", "https://a.example/"}, func(cmd *cli.Command) error {
+		var err error
+		paths, err = writeSplitOutput(cmd, dir, resp)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"pricing-2.md", "pricing-3.md", "c-example.md"}
+	var got []string
+	for _, p := range paths {
+		got = append(got, filepath.Base(p))
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("wrote %v, want %v", got, want)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "my notes" {
+		t.Errorf("existing file was overwritten: %q", data)
+	}
+}
+
+func TestSplitOutputPathsGoToOutputFile(t *testing.T) {
+	srv := newContentsServer(t, 0)
+	dir := t.TempDir()
+	stdout, stderr, err := runCLI(t, srv.URL, "contents", "--split-output", dir, "https://a.example/", "https://b.example/")
+	if err != nil {
+		t.Fatalf("contents --split-output: %v\nstderr: %s", err, stderr)
+	}
+	want := []string{filepath.Join(dir, "a-example.md"), filepath.Join(dir, "b-example.md")}
+	if got := strings.Fields(stdout); !slices.Equal(got, want) {
+		t.Errorf("--output-file got paths %q, want %q", got, want)
+	}
+}
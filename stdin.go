@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/fatih/color"
+	"github.com/toon-format/toon-go"
+	"github.com/urfave/cli/v3"
+)
+
+// stdinFormats are the --output formats search --stdin can write. CSV and
+// mermaid have no way to tell the queries apart.
+var stdinFormats = []string{"table", "report", "markdown", "json", "json-stable", "jsonl", "toon"}
+
+// searchBatch holds the results of search --stdin: each query and its
+// response, in input order
+type searchBatch struct {
+	Queries []queryResponse
+}
+
+// queryResponse is the response to one query of a search --stdin batch
+type queryResponse struct {
+	Query    string
+	Response *client.SearchResponse
+}
+
+// searchBatchOutput is the JSON and TOON output of search --stdin, an object
+// with each query's output under the query. It is kept as a list of fields so
+// the queries are written in input order, which a map wouldn't keep.
+type searchBatchOutput []toon.Field
+
+// MarshalJSON writes the batch as a JSON object keyed by query, in input order
+func (o searchBatchOutput) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// validateStdinSearch checks that the search flags can be combined with
+// --stdin
+func validateStdinSearch(cmd *cli.Command) error {
+	if cmd.Args().Len() > 0 {
+		return fmt.Errorf("--stdin reads the queries from stdin, so don't pass a query argument")
+	}
+	for _, name := range []string{"compare", "merge", "domains-only", "show-related", "checkpoint"} {
+		if cmd.IsSet(name) {
+			return fmt.Errorf("--%s can't be combined with --stdin", name)
+		}
+	}
+	if format := getOutputFormat(cmd); !slices.Contains(stdinFormats, format) && !isQuietMode(cmd) {
+		return fmt.Errorf("--stdin doesn't support --output %s (valid: %s)", format, strings.Join(stdinFormats, ", "))
+	}
+	if cmd.IsSet("results-per-query") {
+		if cmd.IsSet("num-results") {
+			return fmt.Errorf("--results-per-query and --num-results can't be combined")
+		}
+		if cmd.Int("results-per-query") < 1 {
+			return fmt.Errorf("results-per-query must be at least 1")
+		}
+	}
+	if cmd.IsSet("total-limit") && cmd.Int("total-limit") < 1 {
+		return fmt.Errorf("total-limit must be at least 1")
+	}
+	return nil
+}
+
+// readQueries reads one query per line from r until EOF, skipping blank lines
+// and repeats
+func readQueries(r io.Reader) ([]string, error) {
+	var queries []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		query := strings.TrimSpace(scanner.Text())
+		if query == "" || seen[query] {
+			continue
+		}
+		seen[query] = true
+		queries = append(queries, query)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queries: %w", err)
+	}
+	return queries, nil
+}
+
+// searchStdin runs a search for each query read from stdin, one after
+// another, and prints the results grouped by query. The first failed query
+// stops the run unless --continue-on-error (or --best-effort) is set.
+func searchStdin(ctx context.Context, cmd *cli.Command, c *client.Client, minPublished, maxPublished time.Time) error {
+	queries, err := readQueries(os.Stdin)
+	if err != nil {
+		return err
+	}
+	if len(queries) == 0 {
+		return fmt.Errorf("no queries on stdin")
+	}
+
+	failed := newFailures(cmd)
+	if !cmd.Bool("continue-on-error") && !cmd.Root().Bool("best-effort") {
+		failed.failFast = true
+	}
+
+	// Build the request once, so flag warnings (such as for the summary
+	// schema) are printed once for the batch rather than once per query
+	base, err := searchRequest(cmd, queries[0])
+	if err != nil {
+		return err
+	}
+	if cmd.IsSet("results-per-query") {
+		base.NumResults = int(cmd.Int("results-per-query"))
+	}
+	if base.Contents != nil && !cmd.Root().Bool("yes") {
+		opts := contentOptions(base.Contents.Text, base.Contents.Summary, base.Contents.Highlights)
+		if err := confirmExpensive(base.NumResults*len(queries), opts, searchCalls(base.NumResults)*len(queries)); err != nil {
+			return err
+		}
+	}
+
+	batch := &searchBatch{Queries: make([]queryResponse, 0, len(queries))}
+	maxChars := int(cmd.Int("max-chars-total"))
+	var trimmed, removed int
+	ctx, cancel := withTimeout(ctx, cmd)
+	defer cancel()
+	for _, query := range queries {
+		req := *base
+		req.Query = query
+		result, err := searchPaged(ctx, c, &req, "")
+		if err != nil {
+			if err := failed.add(fmt.Sprintf("query %q", query), err); err != nil {
+				return timeoutErr(ctx, err)
+			}
+			continue
+		}
+		if err := filterSearchResults(cmd, query, result, nil, minPublished, maxPublished); err != nil {
+			return err
+		}
+		if maxChars > 0 {
+			t, r := trimTextTotal(result.Results, maxChars)
+			trimmed, removed = trimmed+t, removed+r
+		}
+		batch.Queries = append(batch.Queries, queryResponse{query, result})
+	}
+	if trimmed > 0 {
+		fmt.Fprintf(os.Stderr, "Trimmed text of %d result(s) by %d chars to fit %d total per query\n", trimmed, removed, maxChars)
+	}
+
+	var emptyErr error
+	if cmd.Bool("fail-on-empty") {
+		for _, q := range batch.Queries {
+			if len(q.Response.Results) == 0 {
+				emptyErr = errNoResults
+				break
+			}
+		}
+	}
+	if cmd.IsSet("total-limit") {
+		limitBatch(batch, int(cmd.Int("total-limit")))
+	}
+	return errors.Join(printOutput(cmd, batch), failed.err(), emptyErr)
+}
+
+// limitBatch drops results repeated across the batch's queries, keeping each
+// URL under the query that scored it highest (the first on a tie), then keeps
+// the limit highest-scored results overall. The results left under each query
+// stay in their original order.
+func limitBatch(batch *searchBatch, limit int) {
+	type ranked struct {
+		query, index int
+		score        float64
+	}
+	best := make(map[string]ranked)
+	var urls []string
+	for q, qr := range batch.Queries {
+		for i, r := range qr.Response.Results {
+			prev, seen := best[r.URL]
+			if !seen {
+				urls = append(urls, r.URL)
+			}
+			if !seen || r.Score > prev.score {
+				best[r.URL] = ranked{q, i, r.Score}
+			}
+		}
+	}
+
+	kept := make([]ranked, 0, len(urls))
+	for _, u := range urls {
+		kept = append(kept, best[u])
+	}
+	// Equal scores keep their input order, so without scores the earlier
+	// queries' results are kept
+	slices.SortStableFunc(kept, func(a, b ranked) int {
+		if a.score != b.score {
+			return cmp.Compare(b.score, a.score)
+		}
+		return cmp.Or(cmp.Compare(a.query, b.query), cmp.Compare(a.index, b.index))
+	})
+	keep := make(map[[2]int]bool, limit)
+	for _, k := range kept[:min(limit, len(kept))] {
+		keep[[2]int{k.query, k.index}] = true
+	}
+
+	for q, qr := range batch.Queries {
+		results := make([]client.SearchResult, 0, len(qr.Response.Results))
+		for i, r := range qr.Response.Results {
+			if keep[[2]int{q, i}] {
+				results = append(results, r)
+			}
+		}
+		qr.Response.Results = results
+	}
+}
+
+// printSearchBatch writes each query's results in format under a "Query:"
+// label, separated by blank lines
+func printSearchBatch(w io.Writer, cmd *cli.Command, batch *searchBatch, format string) error {
+	color.NoColor = !colorEnabled()
+	labelFmt := color.New(color.Bold).SprintFunc()
+
+	for i, q := range batch.Queries {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s %s\n\n", labelFmt("Query:"), q.Query)
+		if err := renderFormat(w, cmd, q.Response, format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/12458/exa-cli/internal/client"
+)
+
+// withStdin makes os.Stdin read input for the rest of the test
+func withStdin(t *testing.T, input string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_, _ = w.WriteString(input)
+		_ = w.Close()
+	}()
+	orig := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = orig
+		_ = r.Close()
+	})
+}
+
+// echoSearchServer is a mock /search endpoint returning one result whose
+// title is the query
+func echoSearchServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req client.SearchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		u := "https://example.com/" + strings.ReplaceAll(req.Query, " ", "-")
+		_ = json.NewEncoder(w).Encode(client.SearchResponse{Results: []client.SearchResult{{Title: req.Query, URL: u, ID: u}}})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSearchStdinJSONKeepsInputOrder(t *testing.T) {
+	srv := echoSearchServer(t)
+	withStdin(t, "zebra facts\napple pie\n\nzebra facts\nmango\n")
+
+	stdout, stderr, err := runCLI(t, srv.URL, "--output", "json", "--no-meta", "search", "--stdin")
+	if err != nil {
+		t.Fatalf("search --stdin: %v\nstderr: %s", err, stderr)
+	}
+
+	var out map[string]struct {
+		Results []client.SearchResult `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+		t.Fatalf("output isn't a JSON object: %v\n%s", err, stdout)
+	}
+	want := []string{"zebra facts", "apple pie", "mango"}
+	if len(out) != len(want) {
+		t.Fatalf("got %d queries, want %d (repeats skipped): %s", len(out), len(want), stdout)
+	}
+	for _, query := range want {
+		if results := out[query].Results; len(results) != 1 || results[0].Title != query {
+			t.Errorf("query %q has results %+v, want its own results", query, results)
+		}
+	}
+	if got := jsonKeys(t, stdout); !slices.Equal(got, want) {
+		t.Errorf("queries are in order %q, want input order %q", got, want)
+	}
+}
+
+// jsonKeys returns the keys of the JSON object in data, in the order written
+func jsonKeys(t *testing.T, data string) []string {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, tok.(string))
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keys
+}
+
+func TestSearchStdinTOONKeepsInputOrder(t *testing.T) {
+	srv := echoSearchServer(t)
+	withStdin(t, "zebra facts\napple pie\n")
+
+	stdout, stderr, err := runCLI(t, srv.URL, "--output", "toon", "--project", "title", "search", "--stdin")
+	if err != nil {
+		t.Fatalf("search --stdin: %v\nstderr: %s", err, stderr)
+	}
+	zebra, apple := strings.Index(stdout, `"zebra facts":`), strings.Index(stdout, `"apple pie":`)
+	if zebra < 0 || apple < 0 || zebra > apple {
+		t.Errorf("queries missing or out of order:\n%s", stdout)
+	}
+	if strings.Contains(stdout, "url") {
+		t.Errorf("--project title left other fields in the output:\n%s", stdout)
+	}
+}
+
+// batchOf builds a search batch from query names and "url:score" results
+func batchOf(queries map[string][]string, order ...string) *searchBatch {
+	batch := &searchBatch{}
+	for _, q := range order {
+		resp := &client.SearchResponse{}
+		for _, r := range queries[q] {
+			u, score, _ := strings.Cut(r, ":")
+			f, _ := strconv.ParseFloat(score, 64)
+			resp.Results = append(resp.Results, client.SearchResult{URL: u, Score: f})
+		}
+		batch.Queries = append(batch.Queries, queryResponse{q, resp})
+	}
+	return batch
+}
+
+// batchURLs lists each query's result URLs
+func batchURLs(batch *searchBatch) map[string][]string {
+	urls := make(map[string][]string)
+	for _, q := range batch.Queries {
+		urls[q.Query] = []string{}
+		for _, r := range q.Response.Results {
+			urls[q.Query] = append(urls[q.Query], r.URL)
+		}
+	}
+	return urls
+}
+
+func TestLimitBatch(t *testing.T) {
+	queries := map[string][]string{
+		"a": {"x:0.9", "shared:0.5", "y:0.4"},
+		"b": {"shared:0.8", "z:0.3", "x:0.2"},
+	}
+	tests := []struct {
+		name  string
+		limit int
+		want  map[string][]string
+	}{
+		// Duplicates stay under the query that scored them highest
+		{"dedup only", 10, map[string][]string{"a": {"x", "y"}, "b": {"shared", "z"}}},
+		// The cap keeps the highest scores across queries, after dedup
+		{"cap", 2, map[string][]string{"a": {"x"}, "b": {"shared"}}},
+		{"cap one", 1, map[string][]string{"a": {"x"}, "b": {}}},
+		{"cap three", 3, map[string][]string{"a": {"x", "y"}, "b": {"shared"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := batchOf(queries, "a", "b")
+			limitBatch(batch, tt.limit)
+			if got := batchURLs(batch); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitBatchWithoutScores(t *testing.T) {
+	// Without scores, the earlier queries' results are kept first
+	batch := batchOf(map[string][]string{
+		"a": {"p", "q"},
+		"b": {"q", "r", "s"},
+	}, "a", "b")
+	limitBatch(batch, 3)
+	want := map[string][]string{"a": {"p", "q"}, "b": {"r"}}
+	if got := batchURLs(batch); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSearchStdinResultsPerQueryAndTotalLimit(t *testing.T) {
+	var mu sync.Mutex
+	var asked []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req client.SearchRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		asked = append(asked, req.NumResults)
+		mu.Unlock()
+		// Every query finds the same shared page, scored lower for later ones
+		resp := client.SearchResponse{Results: []client.SearchResult{{URL: "https://shared.example/", Score: 1 / float64(len(req.Query))}}}
+		for i := range req.NumResults - 1 {
+			u := fmt.Sprintf("https://%s.example/%d", strings.ReplaceAll(req.Query, " ", "-"), i)
+			resp.Results = append(resp.Results, client.SearchResult{URL: u, Score: 0.5 - float64(i)/100})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	withStdin(t, "a\nbb\nccc\n")
+
+	stdout, stderr, err := runCLI(t, srv.URL, "--output", "jsonl", "search", "--stdin", "--results-per-query", "4", "--total-limit", "5")
+	if err != nil {
+		t.Fatalf("search --stdin: %v\nstderr: %s", err, stderr)
+	}
+	if !slices.Equal(asked, []int{4, 4, 4}) {
+		t.Errorf("queries asked for %v results, want 4 each", asked)
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d results, want 5:\n%s", len(lines), stdout)
+	}
+	if n := strings.Count(stdout, "shared.example"); n != 1 || !strings.Contains(lines[0], `"query":"a"`) {
+		t.Errorf("want the shared page once, under the query that scored it highest:\n%s", stdout)
+	}
+}
+
+func TestTotalLimitNeedsStdin(t *testing.T) {
+	for _, args := range [][]string{
+		{"search", "--total-limit", "5", "q"},
+		{"search", "--results-per-query", "5", "q"},
+		{"search", "--stdin", "--results-per-query", "5", "-n", "5"},
+		{"search", "--stdin", "--total-limit", "0"},
+	} {
+		withStdin(t, "q\n")
+		_, _, err := runCLI(t, "http://exa.invalid", args...)
+		if err == nil || !strings.Contains(err.Error(), "-limit") && !strings.Contains(err.Error(), "-per-query") {
+			t.Errorf("%v: got %v, want a flag validation error", args, err)
+		}
+	}
+}
+
+func TestSearchStdinFailureOutranksEmpty(t *testing.T) {
+	for _, tt := range []struct {
+		status int
+		want   int
+	}{
+		{http.StatusTooManyRequests, exitRateLimit},
+		{http.StatusInternalServerError, exitAPI},
+	} {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			// "broken" fails with the status and "nothing" finds no results
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req client.SearchRequest
+				_ = json.NewDecoder(r.Body).Decode(&req)
+				if req.Query == "broken" {
+					http.Error(w, `{"error":"failed"}`, tt.status)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(client.SearchResponse{})
+			}))
+			t.Cleanup(srv.Close)
+			withStdin(t, "nothing\nbroken\n")
+
+			_, stderr, err := runCLI(t, srv.URL, "--max-retries", "0", "--output", "jsonl", "search", "--stdin", "--continue-on-error", "--fail-on-empty")
+			if code := exitCode(err); code != tt.want {
+				t.Errorf("exit code %d, want %d: %v\nstderr: %s", code, tt.want, err, stderr)
+			}
+		})
+	}
+}
+
+func TestSearchStdinWarnsOncePerBatch(t *testing.T) {
+	srv := echoSearchServer(t)
+	withStdin(t, "a\nb\nc\n")
+
+	_, stderr, err := runCLI(t, srv.URL, "--output", "jsonl", "search", "--stdin", "--summary", "--summary-schema", `{"description":"not a schema"}`)
+	if err != nil {
+		t.Fatalf("search --stdin: %v\nstderr: %s", err, stderr)
+	}
+	if n := strings.Count(stderr, "may not be a JSON Schema"); n != 1 {
+		t.Errorf("got the schema warning %d times, want once for the batch:\n%s", n, stderr)
+	}
+}
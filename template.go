@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/template"
+
+	"github.com/urfave/cli/v3"
+)
+
+// templateFuncs are the helper functions available to --template-file templates
+var templateFuncs = template.FuncMap{
+	// truncate takes the length first so it works in pipelines:
+	// {{.Title | truncate 40}}
+	"truncate": func(maxLen int, s string) string { return truncate(s, maxLen) },
+	"date":     formatDate,
+	"domain":   resultDomain,
+}
+
+// formatDate reformats a published date with a Go time layout, returning the
+// input unchanged if it can't be parsed
+func formatDate(layout, date string) string {
+	if t, ok := parseDate(date); ok {
+		return t.Format(layout)
+	}
+	return date
+}
+
+// loadTemplate parses the --template-file template together with every *.tmpl
+// file in its directory, so it can include them as partials with
+// {{template "name.tmpl" .}}. It returns nil if --template-file is unset.
+func loadTemplate(cmd *cli.Command) (*template.Template, error) {
+	path := cmd.Root().String("template-file")
+	if path == "" {
+		return nil, nil
+	}
+
+	tmpl := template.New(filepath.Base(path)).Funcs(templateFuncs)
+	pattern := filepath.Join(filepath.Dir(path), "*.tmpl")
+	partials, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template path: %w", err)
+	}
+	// ParseGlob fails when nothing matches, so only use it if there are partials
+	if len(partials) > 0 {
+		if tmpl, err = tmpl.ParseGlob(pattern); err != nil {
+			return nil, fmt.Errorf("failed to parse templates: %w", err)
+		}
+	}
+	if tmpl, err = tmpl.ParseFiles(path); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// printTemplate executes tmpl with v, the search or contents response
+func printTemplate(w io.Writer, tmpl *template.Template, v any) error {
+	if err := tmpl.Execute(w, v); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+	return nil
+}
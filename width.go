@@ -0,0 +1,30 @@
+package main
+
+import (
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// runeWidth returns the number of terminal columns r takes up: 2 for wide
+// East Asian characters (CJK, fullwidth forms, most emoji), 0 for combining
+// marks and invisible format characters, and 1 otherwise
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// displayWidth returns the number of terminal columns s takes up
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
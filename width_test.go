@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/12458/exa-cli/internal/client"
+	"github.com/urfave/cli/v3"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"hello", 5},
+		{"日本語", 6},
+		{"非同期ランタイム", 16},
+		{"한국어 제목", 11},
+		{"ｆｕｌｌ", 8},       // fullwidth Latin
+		{"café", 4},       // precomposed
+		{"cafe\u0301", 4}, // combining acute accent
+		{"🦀 rust", 7},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := displayWidth(tt.in); got != tt.want {
+			t.Errorf("displayWidth(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateMultibyte(t *testing.T) {
+	tests := []struct {
+		in     string
+		maxLen int
+		want   string
+	}{
+		{"short", 10, "short"},
+		{"exactly ten", 11, "exactly ten"},
+		{"a longer ascii title", 10, "a longe..."},
+		// Wide characters take two columns each
+		{"非同期ランタイムの比較", 10, "非同期..."},
+		{"非同期ランタイムの比較", 11, "非同期ラ..."},
+		{"非同期ランタイムの比較", 22, "非同期ランタイムの比較"},
+		{"Rust 非同期ランタイムの比較", 12, "Rust 非同..."},
+		{"🦀🦀🦀🦀🦀🦀", 9, "🦀🦀🦀..."},
+		{"Ünïcödé tïtlé wïth äccents", 10, "Ünïcödé..."},
+		{"e\u0301e\u0301e\u0301e\u0301e\u0301", 4, "e\u0301..."},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.in, tt.maxLen)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.maxLen, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) = %q, not valid UTF-8", tt.in, tt.maxLen, got)
+		}
+		if w := displayWidth(got); w > tt.maxLen {
+			t.Errorf("truncate(%q, %d) is %d columns wide", tt.in, tt.maxLen, w)
+		}
+	}
+}
+
+func TestSearchTableAlignsCJK(t *testing.T) {
+	resp := &client.SearchResponse{Results: []client.SearchResult{
+		{Title: "Rust async runtimes compared", URL: "https://a.example/"},
+		{Title: "非同期ランタイムの比較", URL: "https://b.example/"},
+		{Title: "비동기 런타임 비교와 성능 측정 결과 정리 및 추천 사항에 대한 아주 긴 제목", URL: "https://c.example/"},
+	}}
+	var buf bytes.Buffer
+	err := runCommand(t, []string{"--color", "never", "search", "q"}, func(cmd *cli.Command) error {
+		return renderOutput(&buf, cmd, resp)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The URL column starts at the same display column on every row
+	col := -1
+	for _, line := range strings.Split(buf.String(), "\n") {
+		i := strings.Index(line, "https://")
+		if i < 0 {
+			continue
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("row isn't valid UTF-8: %q", line)
+		}
+		if c := displayWidth(line[:i]); col < 0 {
+			col = c
+		} else if c != col {
+			t.Errorf("URL at column %d, want %d:\n%s", c, col, buf.String())
+		}
+	}
+	if col < 0 {
+		t.Fatalf("no rows in output:\n%s", buf.String())
+	}
+}